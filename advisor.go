@@ -0,0 +1,402 @@
+package grimoire
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// QueryShape describes the fields a query filters and sorts on, without the values.
+type QueryShape struct {
+	// Equality lists the fields compared with $eq or $in, sorted by name.
+	Equality []string
+	// Sort lists the sort fields and directions, in order.
+	Sort bson.D
+	// Range lists the fields compared with any other operator, sorted by name.
+	Range []string
+}
+
+// Key returns a string that identifies the shape.
+func (s QueryShape) Key() string {
+	sorts := make([]string, 0, len(s.Sort))
+	for _, e := range s.Sort {
+		sorts = append(sorts, fmt.Sprintf("%s:%v", e.Key, e.Value))
+	}
+	return fmt.Sprintf("eq(%s) sort(%s) range(%s)", strings.Join(s.Equality, ","), strings.Join(sorts, ","), strings.Join(s.Range, ","))
+}
+
+// Index returns the keys of the index suggested for the shape, following the
+// equality, sort, range rule.
+func (s QueryShape) Index() bson.D {
+	keys := bson.D{}
+	seen := map[string]bool{}
+	add := func(field string, dir interface{}) {
+		if !seen[field] {
+			seen[field] = true
+			keys = append(keys, bson.E{Key: field, Value: dir})
+		}
+	}
+	for _, f := range s.Equality {
+		add(f, 1)
+	}
+	for _, e := range s.Sort {
+		add(e.Key, e.Value)
+	}
+	for _, f := range s.Range {
+		add(f, 1)
+	}
+	return keys
+}
+
+// Shape returns the shape of the query. Clauses that cannot use a single compound index, such
+// as Or and Expr, are not included.
+func (q *QueryBuilder[T]) Shape() QueryShape {
+	eq := map[string]bool{}
+	rng := map[string]bool{}
+	for _, v := range q.clauses() {
+		collectShape(v, eq, rng)
+	}
+
+	shape := QueryShape{Equality: sortedKeys(eq), Sort: append(bson.D{}, q.sort...)}
+	for f := range eq {
+		delete(rng, f)
+	}
+	shape.Range = sortedKeys(rng)
+	return shape
+}
+
+func collectShape(cond bson.M, eq, rng map[string]bool) {
+	for key, value := range cond {
+		if key == "$and" {
+			if list, ok := conditionList(value); ok {
+				for _, c := range list {
+					collectShape(c, eq, rng)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(key, "$") {
+			continue // $or, $nor, $expr
+		}
+
+		ops, ok := value.(bson.M)
+		if !ok {
+			eq[key] = true
+			continue
+		}
+		for op := range ops {
+			if op == "$eq" || op == "$in" {
+				eq[key] = true
+			} else {
+				rng[key] = true
+			}
+		}
+	}
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ShapeStats counts the queries of a shape.
+type ShapeStats struct {
+	Shape QueryShape
+	Count int64
+}
+
+// IndexAdvisor records the shapes of queries executed through a Store, see EnableIndexAdvisor.
+type IndexAdvisor struct {
+	mu     sync.Mutex
+	shapes map[string]*ShapeStats
+}
+
+// NewIndexAdvisor creates an empty index advisor.
+func NewIndexAdvisor() *IndexAdvisor {
+	return &IndexAdvisor{shapes: map[string]*ShapeStats{}}
+}
+
+// Record counts a query of shape.
+func (a *IndexAdvisor) Record(shape QueryShape) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	key := shape.Key()
+	if st, ok := a.shapes[key]; ok {
+		st.Count++
+		return
+	}
+	a.shapes[key] = &ShapeStats{Shape: shape, Count: 1}
+}
+
+// Shapes returns the recorded shapes, most frequent first.
+func (a *IndexAdvisor) Shapes() []ShapeStats {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	list := make([]ShapeStats, 0, len(a.shapes))
+	for _, st := range a.shapes {
+		list = append(list, *st)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Count != list[j].Count {
+			return list[i].Count > list[j].Count
+		}
+		return list[i].Shape.Key() < list[j].Shape.Key()
+	})
+	return list
+}
+
+// IndexInfo describes an index of the collection, or an index declared with struct tags.
+type IndexInfo struct {
+	Name string
+	Keys bson.D
+	// Existing is true when the index exists in the database.
+	Existing bool
+	// Declared is true when the index is declared with grimoire struct tags.
+	Declared bool
+	// Unused is true when none of the recorded query shapes can use the index.
+	Unused bool
+	// Redundant is true when the keys of the index are a prefix of another index.
+	Redundant bool
+	unique    bool
+}
+
+// IndexSuggestion is a compound index suggested for one or more query shapes.
+type IndexSuggestion struct {
+	Keys bson.D
+	// Shapes are the keys of the recorded shapes that would use the index.
+	Shapes []string
+	// Count is the number of recorded queries that would use the index.
+	Count int64
+	// CoveredBy is the name of an existing or declared index that already serves the shapes.
+	CoveredBy string
+}
+
+// IndexReport compares the recorded query shapes with the indexes of a collection.
+type IndexReport struct {
+	Shapes      []ShapeStats
+	Suggestions []IndexSuggestion
+	Indexes     []IndexInfo
+}
+
+func (r *IndexReport) String() string {
+	var b strings.Builder
+	b.WriteString("shapes:\n")
+	for _, st := range r.Shapes {
+		fmt.Fprintf(&b, "  %6d  %s\n", st.Count, st.Shape.Key())
+	}
+	b.WriteString("suggested indexes:\n")
+	for _, sg := range r.Suggestions {
+		status := "missing"
+		if sg.CoveredBy != "" {
+			status = "covered by " + sg.CoveredBy
+		}
+		fmt.Fprintf(&b, "  %s  %s (%d queries)\n", indexName(sg.Keys), status, sg.Count)
+	}
+	b.WriteString("indexes:\n")
+	for _, idx := range r.Indexes {
+		flags := []string{}
+		if idx.Existing {
+			flags = append(flags, "existing")
+		}
+		if idx.Declared {
+			flags = append(flags, "declared")
+		}
+		if idx.Unused {
+			flags = append(flags, "unused")
+		}
+		if idx.Redundant {
+			flags = append(flags, "redundant")
+		}
+		fmt.Fprintf(&b, "  %s  %s\n", idx.Name, strings.Join(flags, ","))
+	}
+	return b.String()
+}
+
+// EnableIndexAdvisor starts recording the shapes of the queries executed through the store's
+// QueryBuilder, for use with IndexReport. Calling it again returns the same advisor, and it can be
+// called while queries run.
+func (s *Store[T]) EnableIndexAdvisor() *IndexAdvisor {
+	if advisor := s.advisor.Load(); advisor != nil {
+		return advisor
+	}
+	s.advisor.CompareAndSwap(nil, NewIndexAdvisor())
+	return s.advisor.Load()
+}
+
+// IndexReport suggests compound indexes for the recorded query shapes, and compares them with
+// the existing indexes of the collection and the indexes declared with struct tags on T.
+func (s *Store[T]) IndexReport() (*IndexReport, error) {
+	existing, err := s.listIndexes()
+	if err != nil {
+		return nil, err
+	}
+
+	shapes := []ShapeStats{}
+	if advisor := s.advisor.Load(); advisor != nil {
+		shapes = advisor.Shapes()
+	}
+	return buildIndexReport(shapes, existing, tagIndexes(modelType[T]())), nil
+}
+
+func (s *Store[T]) listIndexes() ([]IndexInfo, error) {
+	cursor, err := s.Collection.Indexes().List(mgm.Ctx())
+	if err != nil {
+		return nil, err
+	}
+
+	specs := []struct {
+		Name   string `bson:"name"`
+		Key    bson.D `bson:"key"`
+		Unique bool   `bson:"unique"`
+	}{}
+	if err := cursor.All(mgm.Ctx(), &specs); err != nil {
+		return nil, err
+	}
+
+	list := make([]IndexInfo, 0, len(specs))
+	for _, spec := range specs {
+		list = append(list, IndexInfo{Name: spec.Name, Keys: spec.Key, Existing: true, unique: spec.Unique})
+	}
+	return list, nil
+}
+
+func buildIndexReport(shapes []ShapeStats, existing []IndexInfo, declared []bson.D) *IndexReport {
+	r := &IndexReport{Shapes: shapes}
+
+	// merge declared indexes into the existing ones
+	r.Indexes = append(r.Indexes, existing...)
+	for _, keys := range declared {
+		found := false
+		for i := range r.Indexes {
+			if SameIndexKeys(r.Indexes[i].Keys, keys) {
+				r.Indexes[i].Declared = true
+				found = true
+			}
+		}
+		if !found {
+			r.Indexes = append(r.Indexes, IndexInfo{Name: indexName(keys), Keys: keys, Declared: true})
+		}
+	}
+
+	// one suggestion per distinct index, dropping those that are a prefix of another
+	for _, st := range shapes {
+		keys := st.Shape.Index()
+		if len(keys) == 0 {
+			continue
+		}
+		merged := false
+		for i := range r.Suggestions {
+			sg := &r.Suggestions[i]
+			if isPrefix(keys, sg.Keys) {
+				sg.Shapes = append(sg.Shapes, st.Shape.Key())
+				sg.Count += st.Count
+				merged = true
+				break
+			}
+			if isPrefix(sg.Keys, keys) {
+				sg.Keys = keys
+				sg.Shapes = append(sg.Shapes, st.Shape.Key())
+				sg.Count += st.Count
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			r.Suggestions = append(r.Suggestions, IndexSuggestion{Keys: keys, Shapes: []string{st.Shape.Key()}, Count: st.Count})
+		}
+	}
+	for i := range r.Suggestions {
+		for _, idx := range r.Indexes {
+			if isPrefix(r.Suggestions[i].Keys, idx.Keys) {
+				r.Suggestions[i].CoveredBy = idx.Name
+				break
+			}
+		}
+	}
+	sort.SliceStable(r.Suggestions, func(i, j int) bool {
+		return r.Suggestions[i].Count > r.Suggestions[j].Count
+	})
+
+	for i := range r.Indexes {
+		idx := &r.Indexes[i]
+		if idx.Name == "_id_" {
+			continue
+		}
+		idx.Unused = true
+		for _, st := range shapes {
+			if usesIndex(st.Shape, idx.Keys) {
+				idx.Unused = false
+				break
+			}
+		}
+		if idx.unique {
+			continue
+		}
+		for j, other := range r.Indexes {
+			if i != j && len(other.Keys) > len(idx.Keys) && isPrefix(idx.Keys, other.Keys) {
+				idx.Redundant = true
+				break
+			}
+		}
+	}
+
+	return r
+}
+
+// usesIndex returns true when a query of shape can use the index, which requires the first key
+// of the index to be one of the fields of the shape.
+func usesIndex(shape QueryShape, keys bson.D) bool {
+	if len(keys) == 0 {
+		return false
+	}
+	first := keys[0].Key
+	for _, f := range shape.Equality {
+		if f == first {
+			return true
+		}
+	}
+	for _, f := range shape.Range {
+		if f == first {
+			return true
+		}
+	}
+	for _, e := range shape.Sort {
+		if e.Key == first {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrefix returns true when the fields of a are a prefix of the fields of b, with the same
+// directions.
+func isPrefix(a, b bson.D) bool {
+	if len(a) > len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key != b[i].Key || indexKeyValue(a[i].Value) != indexKeyValue(b[i].Value) {
+			return false
+		}
+	}
+	return true
+}
+
+// indexName returns the default name the server gives an index with keys.
+func indexName(keys bson.D) string {
+	parts := make([]string, 0, len(keys))
+	for _, e := range keys {
+		parts = append(parts, fmt.Sprintf("%s_%v", e.Key, e.Value))
+	}
+	return strings.Join(parts, "_")
+}
@@ -0,0 +1,112 @@
+package grimoire
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestQueryBuilder_Shape(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().
+		Where("status", "done").
+		In("medium_id", []string{"a", "b"}).
+		GreaterThan("created_at", 1).
+		And(func(q *QueryBuilder[*Download]) {
+			q.Exists("thash")
+		}).
+		Or(func(q *QueryBuilder[*Download]) {
+			q.Where("auto", true).Where("force", true)
+		}).
+		Desc("updated_at")
+
+	shape := q.Shape()
+	assert.Equal(t, []string{"medium_id", "status"}, shape.Equality)
+	assert.Equal(t, []string{"created_at", "thash"}, shape.Range)
+	assert.Equal(t, bson.D{
+		{Key: "medium_id", Value: 1},
+		{Key: "status", Value: 1},
+		{Key: "updated_at", Value: -1},
+		{Key: "created_at", Value: 1},
+		{Key: "thash", Value: 1},
+	}, shape.Index())
+}
+
+func TestStore_EnableIndexAdvisor(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+
+	// enabled while queries run, checked with -race
+	wg := sync.WaitGroup{}
+	advisors := make([]*IndexAdvisor, 10)
+	for i := 0; i < 10; i++ {
+		wg.Add(2)
+		go func(i int) {
+			defer wg.Done()
+			advisors[i] = s.EnableIndexAdvisor()
+		}(i)
+		go func() {
+			defer wg.Done()
+			assert.NoError(t, s.Query().Where("status", "done").before("find"))
+		}()
+	}
+	wg.Wait()
+
+	for _, a := range advisors {
+		assert.Same(t, advisors[0], a)
+	}
+	assert.NoError(t, s.Query().Where("status", "done").before("find"))
+	assert.NotEmpty(t, s.EnableIndexAdvisor().Shapes())
+}
+
+func TestIndexReport(t *testing.T) {
+	s, err := New[*Fake]("mongodb://localhost:27017", "grimoire", "fakes")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	a := s.EnableIndexAdvisor()
+	a.Record(s.Query().Where("name", "blarg").Desc("created_at").Shape())
+	a.Record(s.Query().Where("name", "blarg").Desc("created_at").Shape())
+	a.Record(s.Query().Where("name", "blarg").Shape())
+	a.Record(s.Query().GreaterThan("age", 10).Shape())
+
+	existing := []IndexInfo{
+		{Name: "_id_", Keys: bson.D{{Key: "_id", Value: int32(1)}}, Existing: true},
+		{Name: "name_1", Keys: bson.D{{Key: "name", Value: int32(1)}}, Existing: true},
+		{Name: "status_1", Keys: bson.D{{Key: "status", Value: int32(1)}}, Existing: true},
+	}
+	r := buildIndexReport(a.Shapes(), existing, tagIndexes(modelType[*Fake]()))
+
+	assert.Len(t, r.Shapes, 3)
+	assert.Equal(t, int64(2), r.Shapes[0].Count)
+
+	assert.Len(t, r.Suggestions, 2)
+	assert.Equal(t, bson.D{{Key: "name", Value: 1}, {Key: "created_at", Value: -1}}, r.Suggestions[0].Keys)
+	assert.Equal(t, int64(3), r.Suggestions[0].Count)
+	assert.Equal(t, "", r.Suggestions[0].CoveredBy)
+	assert.Equal(t, bson.D{{Key: "age", Value: 1}}, r.Suggestions[1].Keys)
+	assert.Equal(t, "", r.Suggestions[1].CoveredBy, "declared index is descending")
+
+	byName := map[string]IndexInfo{}
+	for _, idx := range r.Indexes {
+		byName[idx.Name] = idx
+	}
+	assert.True(t, byName["name_1"].Declared)
+	assert.True(t, byName["name_1"].Existing)
+	assert.False(t, byName["name_1"].Unused)
+	assert.True(t, byName["status_1"].Unused)
+	assert.True(t, byName["age_-1"].Declared)
+	assert.False(t, byName["age_-1"].Existing)
+	assert.False(t, byName["age_-1"].Unused)
+	assert.False(t, byName["_id_"].Unused)
+
+	r = buildIndexReport(nil, append(existing, IndexInfo{Name: "name_1_age_1", Keys: bson.D{{Key: "name", Value: 1}, {Key: "age", Value: 1}}, Existing: true}), nil)
+	for _, idx := range r.Indexes {
+		assert.Equal(t, idx.Name == "name_1", idx.Redundant, idx.Name)
+	}
+}
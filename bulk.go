@@ -0,0 +1,256 @@
+package grimoire
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// SaveMany inserts the new documents of list and updates the others, in a single bulk write.
+// All documents are validated first, and nothing is written when one of them is invalid: the
+// error is then a *ValidationError with paths prefixed by the index of the document, e.g.
+// "2.status". The before hooks of the models, such as the timestamps of Document, are called,
+// the after hooks are not.
+//
+// Example:
+//
+//	err := s.SaveMany([]*Download{d1, d2, d3})
+func (s *Store[T]) SaveMany(list []T) error {
+	return s.SaveManyWithContext(mgm.Ctx(), list)
+}
+
+// SaveManyWithContext is SaveMany with a context, which carries the tenant of tenant stores.
+// Nothing is written when a document to update does not belong to the tenant: the error is then
+// ErrTenantMismatch, listing the ids of those documents.
+func (s *Store[T]) SaveManyWithContext(ctx context.Context, list []T) error {
+	if len(list) == 0 {
+		return nil
+	}
+	for _, o := range list {
+		if err := s.stampTenant(ctx, o); err != nil {
+			return err
+		}
+	}
+	if err := validateMany(list); err != nil {
+		return err
+	}
+	checked, err := s.checkTenantIDs(ctx, list)
+	if err != nil {
+		return err
+	}
+
+	models := make([]mongo.WriteModel, 0, len(list))
+	ids := make([]primitive.ObjectID, len(list))
+	for i, o := range list {
+		id := o.GetID().(primitive.ObjectID)
+		if id.IsZero() {
+			if err := beforeCreate(ctx, o); err != nil {
+				return err
+			}
+			ids[i] = primitive.NewObjectID()
+			models = append(models, mongo.NewInsertOneModel().SetDocument(o))
+			continue
+		}
+
+		if err := beforeUpdate(ctx, o); err != nil {
+			return err
+		}
+		filter, err := s.tenantFilter(ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		models = append(models, mongo.NewUpdateOneModel().SetFilter(filter).SetUpdate(bson.M{"$set": o}))
+	}
+	// the documents are encoded by BulkWrite, with their new ids
+	for i, id := range ids {
+		if !id.IsZero() {
+			list[i].SetID(id)
+		}
+	}
+
+	err = s.observe(ctx, operation{name: "bulk"}, func(ctx context.Context) (int64, error) {
+		res, err := s.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(true))
+		if res == nil {
+			return 0, err
+		}
+		if err == nil && res.MatchedCount < checked {
+			// documents moved to another tenant since checkTenantIDs
+			err = fmt.Errorf("grimoire: %w: %d of %d documents not updated", ErrTenantMismatch, checked-res.MatchedCount, checked)
+		}
+		return res.InsertedCount + res.ModifiedCount, err
+	})
+
+	if err == nil {
+		return nil
+	}
+
+	// ordered writes stop at the first error, the new documents after it are not inserted
+	written := 0
+	if bwe, ok := err.(mongo.BulkWriteException); ok && len(bwe.WriteErrors) > 0 {
+		written = bwe.WriteErrors[0].Index
+	}
+	for i := written; i < len(list); i++ {
+		if !ids[i].IsZero() {
+			list[i].SetID(primitive.NilObjectID)
+		}
+	}
+	return err
+}
+
+// checkTenantIDs returns an error unless the documents of list to update are stored, and belong
+// to the tenant of ctx, and returns the number of documents checked. Stores without tenancy are
+// not checked.
+func (s *Store[T]) checkTenantIDs(ctx context.Context, list []T) (int64, error) {
+	ids := []primitive.ObjectID{}
+	for _, o := range list {
+		if id := o.GetID().(primitive.ObjectID); !id.IsZero() {
+			ids = append(ids, id)
+		}
+	}
+	tenant, err := s.tenant(ctx)
+	if err != nil || tenant == "" || len(ids) == 0 {
+		return 0, err
+	}
+
+	cursor, err := s.Collection.Find(ctx, bson.M{"_id": bson.M{"$in": ids}, s.tenantField: tenant},
+		options.Find().SetProjection(bson.M{"_id": 1}))
+	if err != nil {
+		return 0, err
+	}
+	found := []struct {
+		ID primitive.ObjectID `bson:"_id"`
+	}{}
+	if err := cursor.All(ctx, &found); err != nil {
+		return 0, err
+	}
+	stored := make(map[primitive.ObjectID]bool, len(found))
+	for _, f := range found {
+		stored[f.ID] = true
+	}
+
+	missing := []string{}
+	for _, id := range ids {
+		if !stored[id] {
+			missing = append(missing, id.Hex())
+		}
+	}
+	if len(missing) > 0 {
+		return 0, fmt.Errorf("grimoire: %w: %s", ErrTenantMismatch, strings.Join(missing, ", "))
+	}
+	return int64(len(ids)), nil
+}
+
+// validateMany validates the documents of list, prefixing the paths of the failing fields with
+// the index of their document.
+func validateMany[T mgm.Model](list []T) error {
+	verr := &ValidationError{}
+	for i, o := range list {
+		err := Validate(o)
+		if err == nil {
+			continue
+		}
+		other, ok := err.(*ValidationError)
+		if !ok {
+			return err
+		}
+		for _, f := range other.Fields {
+			if f.Path == "" {
+				f.Path = strconv.Itoa(i)
+			} else {
+				f.Path = strconv.Itoa(i) + "." + f.Path
+			}
+			verr.Fields = append(verr.Fields, f)
+		}
+	}
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// beforeCreate calls the hooks mgm calls before inserting o.
+func beforeCreate(ctx context.Context, o mgm.Model) error {
+	if h, ok := o.(mgm.CreatingHookWithCtx); ok {
+		if err := h.Creating(ctx); err != nil {
+			return err
+		}
+	} else if h, ok := o.(mgm.CreatingHook); ok {
+		if err := h.Creating(); err != nil {
+			return err
+		}
+	}
+	return saving(ctx, o)
+}
+
+// beforeUpdate calls the hooks mgm calls before updating o.
+func beforeUpdate(ctx context.Context, o mgm.Model) error {
+	if h, ok := o.(mgm.UpdatingHookWithCtx); ok {
+		if err := h.Updating(ctx); err != nil {
+			return err
+		}
+	} else if h, ok := o.(mgm.UpdatingHook); ok {
+		if err := h.Updating(); err != nil {
+			return err
+		}
+	}
+	return saving(ctx, o)
+}
+
+// afterUpdate calls the hooks mgm calls after updating o.
+func afterUpdate(ctx context.Context, res *mongo.UpdateResult, o mgm.Model) error {
+	if h, ok := o.(mgm.UpdatedHookWithCtx); ok {
+		if err := h.Updated(ctx, res); err != nil {
+			return err
+		}
+	} else if h, ok := o.(mgm.UpdatedHook); ok {
+		if err := h.Updated(res); err != nil {
+			return err
+		}
+	}
+	if h, ok := o.(mgm.SavedHookWithCtx); ok {
+		return h.Saved(ctx)
+	}
+	if h, ok := o.(mgm.SavedHook); ok {
+		return h.Saved()
+	}
+	return nil
+}
+
+// beforeDelete calls the hooks mgm calls before deleting o.
+func beforeDelete(ctx context.Context, o mgm.Model) error {
+	if h, ok := o.(mgm.DeletingHookWithCtx); ok {
+		return h.Deleting(ctx)
+	}
+	if h, ok := o.(mgm.DeletingHook); ok {
+		return h.Deleting()
+	}
+	return nil
+}
+
+// afterDelete calls the hooks mgm calls after deleting o.
+func afterDelete(ctx context.Context, res *mongo.DeleteResult, o mgm.Model) error {
+	if h, ok := o.(mgm.DeletedHookWithCtx); ok {
+		return h.Deleted(ctx, res)
+	}
+	if h, ok := o.(mgm.DeletedHook); ok {
+		return h.Deleted(res)
+	}
+	return nil
+}
+
+func saving(ctx context.Context, o mgm.Model) error {
+	if h, ok := o.(mgm.SavingHookWithCtx); ok {
+		return h.Saving(ctx)
+	}
+	if h, ok := o.(mgm.SavingHook); ok {
+		return h.Saving()
+	}
+	return nil
+}
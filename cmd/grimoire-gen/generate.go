@@ -0,0 +1,514 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"io/fs"
+	"path"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+
+	"github.com/dashotv/grimoire"
+)
+
+const grimoirePath = "github.com/dashotv/grimoire"
+
+// model is a struct embedding grimoire.Document.
+type model struct {
+	Name    string
+	Fields  []field
+	Indexes [][]indexKey
+}
+
+// field is a field of a model, or of a struct nested in a model.
+type field struct {
+	// Ident is the Go path of the field, e.g. TimestampsFound.
+	Ident string
+	// Path is the BSON path of the field, e.g. timestamps.found.
+	Path string
+	// Type is the Go type of the values of the field, or of its elements for slices. It is empty
+	// for nested structs, which have a constant but no query method.
+	Type string
+	// Method is the name of the query method of the field.
+	Method string
+}
+
+type indexKey struct {
+	Name  string
+	Value int
+}
+
+// source is the parsed package of the models.
+type source struct {
+	name    string
+	order   []string
+	structs map[string]*ast.StructType
+	files   map[string]*ast.File
+	// imports are the imports needed by the field types, keyed by local name.
+	imports map[string]string
+}
+
+// generate returns the generated code for the models names of the package in dir, or for all
+// of its models when names is empty.
+func generate(dir string, names []string) ([]byte, error) {
+	src, err := parseSource(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	reserved := reservedMethods()
+	models := []*model{}
+	for _, name := range src.order {
+		if len(names) > 0 && !contains(names, name) {
+			continue
+		}
+		st, file := src.structs[name], src.files[name]
+		if !src.isModel(st, file) {
+			if len(names) > 0 {
+				return nil, fmt.Errorf("%s does not embed grimoire.Document", name)
+			}
+			continue
+		}
+
+		m := &model{Name: name}
+		if err := src.walk(m, st, file, "", "", 0); err != nil {
+			return nil, fmt.Errorf("%s: %w", name, err)
+		}
+		seen := map[string]bool{}
+		for i, f := range m.Fields {
+			if seen[f.Ident] {
+				return nil, fmt.Errorf("%s: duplicate field %s", name, f.Ident)
+			}
+			seen[f.Ident] = true
+			m.Fields[i].Method = f.Ident
+			if reserved[f.Ident] {
+				m.Fields[i].Method = f.Ident + "Field"
+			}
+		}
+		models = append(models, m)
+	}
+	for _, name := range names {
+		if _, ok := src.structs[name]; !ok {
+			return nil, fmt.Errorf("unknown type %s", name)
+		}
+	}
+	if len(models) == 0 {
+		return nil, fmt.Errorf("no models in %s", dir)
+	}
+
+	return render(src, models)
+}
+
+func parseSource(dir string) (*source, error) {
+	fset := token.NewFileSet()
+	filter := func(fi fs.FileInfo) bool { return !strings.HasSuffix(fi.Name(), "_test.go") }
+	pkgs, err := parser.ParseDir(fset, dir, filter, parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+	if len(pkgs) != 1 {
+		return nil, fmt.Errorf("expected one package in %s, found %d", dir, len(pkgs))
+	}
+
+	src := &source{
+		structs: map[string]*ast.StructType{},
+		files:   map[string]*ast.File{},
+		imports: map[string]string{},
+	}
+	for _, pkg := range pkgs {
+		src.name = pkg.Name
+		filenames := make([]string, 0, len(pkg.Files))
+		for filename := range pkg.Files {
+			filenames = append(filenames, filename)
+		}
+		sort.Strings(filenames)
+
+		for _, filename := range filenames {
+			file := pkg.Files[filename]
+			if ast.IsGenerated(file) {
+				continue
+			}
+			for _, decl := range file.Decls {
+				gd, ok := decl.(*ast.GenDecl)
+				if !ok || gd.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range gd.Specs {
+					ts := spec.(*ast.TypeSpec)
+					if st, ok := ts.Type.(*ast.StructType); ok && ts.TypeParams == nil {
+						src.order = append(src.order, ts.Name.Name)
+						src.structs[ts.Name.Name] = st
+						src.files[ts.Name.Name] = file
+					}
+				}
+			}
+		}
+	}
+	return src, nil
+}
+
+// isModel returns true when st embeds grimoire.Document.
+func (s *source) isModel(st *ast.StructType, file *ast.File) bool {
+	for _, f := range st.Fields.List {
+		if len(f.Names) == 0 && isDocument(f.Type, file) {
+			return true
+		}
+	}
+	return false
+}
+
+func isDocument(expr ast.Expr, file *ast.File) bool {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	sel, ok := expr.(*ast.SelectorExpr)
+	if !ok || sel.Sel.Name != "Document" {
+		return false
+	}
+	x, ok := sel.X.(*ast.Ident)
+	return ok && importPath(file, x.Name) == grimoirePath
+}
+
+// walk adds the fields of st to m, with BSON paths prefixed with prefix and Go paths prefixed
+// with ident.
+func (s *source) walk(m *model, st *ast.StructType, file *ast.File, prefix, ident string, depth int) error {
+	if depth > 4 {
+		return nil
+	}
+
+	for _, f := range st.Fields.List {
+		tag := reflect.StructTag("")
+		if f.Tag != nil {
+			v, err := strconv.Unquote(f.Tag.Value)
+			if err != nil {
+				return err
+			}
+			tag = reflect.StructTag(v)
+		}
+
+		if len(f.Names) == 0 {
+			if isDocument(f.Type, file) {
+				s.imports["primitive"] = "go.mongodb.org/mongo-driver/bson/primitive"
+				s.imports["time"] = "time"
+				m.Fields = append(m.Fields,
+					field{Ident: ident + "ID", Path: prefix + "_id", Type: "primitive.ObjectID"},
+					field{Ident: ident + "CreatedAt", Path: prefix + "created_at", Type: "time.Time"},
+					field{Ident: ident + "UpdatedAt", Path: prefix + "updated_at", Type: "time.Time"},
+				)
+				continue
+			}
+			if _, inline, _ := bsonName("", tag); inline {
+				if inner, file := s.localStruct(f.Type, file); inner != nil {
+					if err := s.walk(m, inner, file, prefix, ident, depth+1); err != nil {
+						return err
+					}
+				}
+			}
+			continue
+		}
+
+		for _, n := range f.Names {
+			if !n.IsExported() {
+				continue
+			}
+			name, inline, skip := bsonName(n.Name, tag)
+			if skip {
+				continue
+			}
+			if inline {
+				if inner, file := s.localStruct(f.Type, file); inner != nil {
+					if err := s.walk(m, inner, file, prefix, ident, depth+1); err != nil {
+						return err
+					}
+				}
+				continue
+			}
+
+			fd := field{Ident: ident + n.Name, Path: prefix + name}
+			// like grimoire.CreateIndexesFromTags, only the fields of the model itself are indexed
+			if depth == 0 {
+				keys, err := indexKeys(fd.Path, tag)
+				if err != nil {
+					return fmt.Errorf("%s.%s: %w", m.Name, n.Name, err)
+				}
+				if keys != nil {
+					m.Indexes = append(m.Indexes, keys)
+				}
+			}
+
+			typ := elem(f.Type)
+			if inner, innerFile := s.localStruct(typ, file); inner != nil {
+				m.Fields = append(m.Fields, fd)
+				if err := s.walk(m, inner, innerFile, fd.Path+".", fd.Ident, depth+1); err != nil {
+					return err
+				}
+				continue
+			}
+
+			fd.Type = types.ExprString(typ)
+			if err := s.addImports(typ, file); err != nil {
+				return fmt.Errorf("%s: %w", n.Name, err)
+			}
+			m.Fields = append(m.Fields, fd)
+		}
+	}
+	return nil
+}
+
+// localStruct returns the struct of expr when it is an anonymous struct or a struct declared in
+// the package, with the file it is declared in.
+func (s *source) localStruct(expr ast.Expr, file *ast.File) (*ast.StructType, *ast.File) {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	switch t := expr.(type) {
+	case *ast.StructType:
+		return t, file
+	case *ast.Ident:
+		if st, ok := s.structs[t.Name]; ok {
+			return st, s.files[t.Name]
+		}
+	}
+	return nil, nil
+}
+
+// addImports records the imports of the packages used by expr.
+func (s *source) addImports(expr ast.Expr, file *ast.File) error {
+	var err error
+	ast.Inspect(expr, func(n ast.Node) bool {
+		sel, ok := n.(*ast.SelectorExpr)
+		if !ok {
+			return true
+		}
+		if x, ok := sel.X.(*ast.Ident); ok {
+			p := importPath(file, x.Name)
+			if p == "" {
+				err = fmt.Errorf("unknown package %s", x.Name)
+			}
+			s.imports[x.Name] = p
+		}
+		return false
+	})
+	return err
+}
+
+// elem returns the type of the values of a field of type expr: the element type of slices and
+// arrays, without pointers. Byte slices are kept.
+func elem(expr ast.Expr) ast.Expr {
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	if arr, ok := expr.(*ast.ArrayType); ok {
+		if id, ok := arr.Elt.(*ast.Ident); ok && (id.Name == "byte" || id.Name == "uint8") {
+			return expr
+		}
+		expr = arr.Elt
+	}
+	if star, ok := expr.(*ast.StarExpr); ok {
+		expr = star.X
+	}
+	return expr
+}
+
+// importPath returns the path of the package imported as name in file.
+func importPath(file *ast.File, name string) string {
+	for _, imp := range file.Imports {
+		p, err := strconv.Unquote(imp.Path.Value)
+		if err != nil {
+			continue
+		}
+		local := path.Base(p)
+		if imp.Name != nil {
+			local = imp.Name.Name
+		}
+		if local == name {
+			return p
+		}
+	}
+	return ""
+}
+
+// bsonName returns the BSON key of the field name, following the rules of the driver.
+func bsonName(name string, tag reflect.StructTag) (string, bool, bool) {
+	name = strings.ToLower(name)
+	v, ok := tag.Lookup("bson")
+	if !ok {
+		return name, false, false
+	}
+	if v == "-" {
+		return "", false, true
+	}
+	vals := strings.Split(v, ",")
+	if vals[0] != "" {
+		name = vals[0]
+	}
+	return name, contains(vals[1:], "inline"), false
+}
+
+// indexKeys returns the keys of the index declared by the index option of a grimoire tag, e.g.
+// `grimoire:"index,desc"` or `grimoire:"required,index"`, like grimoire.CreateIndexesFromTags.
+// Tags that grimoire.ParseTag rejects are returned as errors.
+func indexKeys(path string, tag reflect.StructTag) ([]indexKey, error) {
+	v, ok := tag.Lookup("grimoire")
+	if !ok || v == "" {
+		return nil, nil
+	}
+	t, err := grimoire.ParseTag(v)
+	if err != nil || t.Index == 0 {
+		return nil, err
+	}
+	return []indexKey{{Name: path, Value: t.Index}}, nil
+}
+
+// reservedMethods returns the methods of grimoire.QueryBuilder, which field methods should not
+// shadow.
+func reservedMethods() map[string]bool {
+	reserved := map[string]bool{"QueryBuilder": true}
+	t := reflect.TypeOf(&grimoire.QueryBuilder[*grimoire.Document]{})
+	for i := 0; i < t.NumMethod(); i++ {
+		reserved[t.Method(i).Name] = true
+	}
+	return reserved
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
+
+func render(src *source, models []*model) ([]byte, error) {
+	imports := map[string]string{
+		"context":  "context",
+		"grimoire": grimoirePath,
+		"mongo":    "go.mongodb.org/mongo-driver/mongo",
+	}
+	for _, m := range models {
+		if len(m.Indexes) > 0 {
+			imports["bson"] = "go.mongodb.org/mongo-driver/bson"
+		}
+		for _, f := range m.Fields {
+			for name, p := range src.imports {
+				if strings.Contains(f.Type, name+".") {
+					imports[name] = p
+				}
+			}
+		}
+	}
+
+	// standard packages first, then the others
+	std, other := []string{}, []string{}
+	for name, p := range imports {
+		spec := strconv.Quote(p)
+		if path.Base(p) != name {
+			spec = name + " " + spec
+		}
+		if strings.Contains(strings.Split(p, "/")[0], ".") {
+			other = append(other, spec)
+		} else {
+			std = append(std, spec)
+		}
+	}
+	sort.Strings(std)
+	sort.Strings(other)
+	list := std
+	if len(std) > 0 && len(other) > 0 {
+		list = append(list, "")
+	}
+	list = append(list, other...)
+
+	buf := &bytes.Buffer{}
+	err := codeTemplate.Execute(buf, map[string]interface{}{
+		"Package": src.name,
+		"Imports": list,
+		"Models":  models,
+	})
+	if err != nil {
+		return nil, err
+	}
+	out, err := format.Source(buf.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("format: %w\n%s", err, buf.Bytes())
+	}
+	return out, nil
+}
+
+var codeTemplate = template.Must(template.New("code").Parse(`// Code generated by grimoire-gen. DO NOT EDIT.
+
+package {{.Package}}
+
+import (
+{{- range .Imports}}
+	{{.}}
+{{- end}}
+)
+{{range .Models}}{{$m := .Name}}
+// BSON paths of the fields of {{$m}}.
+const (
+{{- range .Fields}}
+	{{$m}}Field{{.Ident}} = {{printf "%q" .Path}}
+{{- end}}
+)
+
+// {{$m}}Indexes are the indexes declared by the grimoire tags of {{$m}}.
+var {{$m}}Indexes = []mongo.IndexModel{
+{{- range .Indexes}}
+	{Keys: bson.D{ {{- range $i, $k := .}}{{if $i}}, {{end}}{Key: {{printf "%q" $k.Name}}, Value: {{$k.Value}}}{{end -}} }},
+{{- end}}
+}
+
+// {{$m}}Store is a store of {{$m}} documents, with a typed query builder.
+type {{$m}}Store struct {
+	*grimoire.Store[*{{$m}}]
+}
+
+// New{{$m}}Store creates a store of {{$m}} documents, see grimoire.New.
+func New{{$m}}Store(URI, database, collection string) (*{{$m}}Store, error) {
+	s, err := grimoire.New[*{{$m}}](URI, database, collection)
+	if err != nil {
+		return nil, err
+	}
+	return &{{$m}}Store{Store: s}, nil
+}
+
+// Query returns a typed query builder of the store.
+func (s *{{$m}}Store) Query() *{{$m}}Query {
+	return wrap{{$m}}Query(s.Store.Query())
+}
+
+// CreateIndexes creates {{$m}}Indexes on the collection of the store.
+func (s *{{$m}}Store) CreateIndexes(ctx context.Context) error {
+	if len({{$m}}Indexes) == 0 {
+		return nil
+	}
+	_, err := s.Collection.Indexes().CreateMany(ctx, {{$m}}Indexes)
+	return err
+}
+
+// {{$m}}Query is a query builder of {{$m}} documents, with a method for each field.
+// The methods of grimoire.QueryBuilder return the *grimoire.QueryBuilder, so call the field
+// methods before them in a chain, see grimoire.Field.
+type {{$m}}Query struct {
+	*grimoire.QueryBuilder[*{{$m}}]
+}
+
+func wrap{{$m}}Query(q *grimoire.QueryBuilder[*{{$m}}]) *{{$m}}Query {
+	return &{{$m}}Query{QueryBuilder: q}
+}
+{{range .Fields}}{{if .Type}}
+// {{.Method}} returns the {{.Path}} field of the query.
+func (q *{{$m}}Query) {{.Method}}() grimoire.Field[*{{$m}}, *{{$m}}Query, {{.Type}}] {
+	return grimoire.NewField[*{{$m}}, *{{$m}}Query, {{.Type}}](q.QueryBuilder, {{$m}}Field{{.Ident}}, wrap{{$m}}Query)
+}
+{{end}}{{end}}{{end}}`))
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/dashotv/grimoire"
+)
+
+func TestGenerate(t *testing.T) {
+	dir := filepath.Join("internal", "example")
+	src, err := generate(dir, nil)
+	assert.NoError(t, err)
+
+	// the checked-in code is up to date, and compiles with the rest of the module
+	expected, err := os.ReadFile(filepath.Join(dir, "grimoire_gen.go"))
+	assert.NoError(t, err)
+	assert.Equal(t, string(expected), string(src), "run go generate ./cmd/grimoire-gen/...")
+
+	code := string(src)
+	assert.Contains(t, code, `DownloadFieldTimestampsFound     = "timestamps.found"`)
+	assert.Contains(t, code, `{Keys: bson.D{{Key: "status", Value: 1}}},`)
+	assert.NotContains(t, code, `{Keys: bson.D{{Key: "timestamps.found"`, "nested fields are not indexed, like CreateIndexesFromTags")
+	assert.Contains(t, code, "func (q *DownloadQuery) LimitField() grimoire.Field[*Download, *DownloadQuery, int]", "reserved name")
+	assert.Contains(t, code, "func (q *DownloadQuery) Tags() grimoire.Field[*Download, *DownloadQuery, string]", "slice element")
+	assert.NotContains(t, code, "DownloadFieldMedium ", "bson:\"-\"")
+	assert.NotContains(t, code, "notes")
+	assert.NotContains(t, code, "FileStore", "not a model")
+
+	src, err = generate(dir, []string{"Medium"})
+	assert.NoError(t, err)
+	assert.NotContains(t, string(src), "Download")
+
+	_, err = generate(dir, []string{"File"})
+	assert.EqualError(t, err, "File does not embed grimoire.Document")
+	_, err = generate(dir, []string{"Missing"})
+	assert.EqualError(t, err, "unknown type Missing")
+}
+
+func TestIndexKeys(t *testing.T) {
+	keys, err := indexKeys("status", `bson:"status" grimoire:"required,index,desc"`)
+	assert.NoError(t, err)
+	assert.Equal(t, []indexKey{{Name: "status", Value: -1}}, keys)
+
+	keys, err = indexKeys("status", `bson:"status" grimoire:"required"`)
+	assert.NoError(t, err)
+	assert.Nil(t, keys)
+
+	_, err = indexKeys("status", `bson:"status" grimoire:"index,unique"`)
+	assert.ErrorIs(t, err, grimoire.ErrInvalidTag)
+}
@@ -0,0 +1,212 @@
+// Code generated by grimoire-gen. DO NOT EDIT.
+
+package example
+
+import (
+	"context"
+	"time"
+
+	"github.com/dashotv/grimoire"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// BSON paths of the fields of Download.
+const (
+	DownloadFieldID                  = "_id"
+	DownloadFieldCreatedAt           = "created_at"
+	DownloadFieldUpdatedAt           = "updated_at"
+	DownloadFieldMediumID            = "medium_id"
+	DownloadFieldStatus              = "status"
+	DownloadFieldSize                = "size"
+	DownloadFieldTags                = "tags"
+	DownloadFieldLimit               = "limit"
+	DownloadFieldTimestamps          = "timestamps"
+	DownloadFieldTimestampsFound     = "timestamps.found"
+	DownloadFieldTimestampsCompleted = "timestamps.completed"
+	DownloadFieldFiles               = "files"
+	DownloadFieldFilesName           = "files.name"
+	DownloadFieldFilesNum            = "files.num"
+)
+
+// DownloadIndexes are the indexes declared by the grimoire tags of Download.
+var DownloadIndexes = []mongo.IndexModel{
+	{Keys: bson.D{{Key: "medium_id", Value: 1}}},
+	{Keys: bson.D{{Key: "status", Value: 1}}},
+}
+
+// DownloadStore is a store of Download documents, with a typed query builder.
+type DownloadStore struct {
+	*grimoire.Store[*Download]
+}
+
+// NewDownloadStore creates a store of Download documents, see grimoire.New.
+func NewDownloadStore(URI, database, collection string) (*DownloadStore, error) {
+	s, err := grimoire.New[*Download](URI, database, collection)
+	if err != nil {
+		return nil, err
+	}
+	return &DownloadStore{Store: s}, nil
+}
+
+// Query returns a typed query builder of the store.
+func (s *DownloadStore) Query() *DownloadQuery {
+	return wrapDownloadQuery(s.Store.Query())
+}
+
+// CreateIndexes creates DownloadIndexes on the collection of the store.
+func (s *DownloadStore) CreateIndexes(ctx context.Context) error {
+	if len(DownloadIndexes) == 0 {
+		return nil
+	}
+	_, err := s.Collection.Indexes().CreateMany(ctx, DownloadIndexes)
+	return err
+}
+
+// DownloadQuery is a query builder of Download documents, with a method for each field.
+// The methods of grimoire.QueryBuilder return the *grimoire.QueryBuilder, so call the field
+// methods before them in a chain, see grimoire.Field.
+type DownloadQuery struct {
+	*grimoire.QueryBuilder[*Download]
+}
+
+func wrapDownloadQuery(q *grimoire.QueryBuilder[*Download]) *DownloadQuery {
+	return &DownloadQuery{QueryBuilder: q}
+}
+
+// ID returns the _id field of the query.
+func (q *DownloadQuery) ID() grimoire.Field[*Download, *DownloadQuery, primitive.ObjectID] {
+	return grimoire.NewField[*Download, *DownloadQuery, primitive.ObjectID](q.QueryBuilder, DownloadFieldID, wrapDownloadQuery)
+}
+
+// CreatedAt returns the created_at field of the query.
+func (q *DownloadQuery) CreatedAt() grimoire.Field[*Download, *DownloadQuery, time.Time] {
+	return grimoire.NewField[*Download, *DownloadQuery, time.Time](q.QueryBuilder, DownloadFieldCreatedAt, wrapDownloadQuery)
+}
+
+// UpdatedAt returns the updated_at field of the query.
+func (q *DownloadQuery) UpdatedAt() grimoire.Field[*Download, *DownloadQuery, time.Time] {
+	return grimoire.NewField[*Download, *DownloadQuery, time.Time](q.QueryBuilder, DownloadFieldUpdatedAt, wrapDownloadQuery)
+}
+
+// MediumID returns the medium_id field of the query.
+func (q *DownloadQuery) MediumID() grimoire.Field[*Download, *DownloadQuery, primitive.ObjectID] {
+	return grimoire.NewField[*Download, *DownloadQuery, primitive.ObjectID](q.QueryBuilder, DownloadFieldMediumID, wrapDownloadQuery)
+}
+
+// Status returns the status field of the query.
+func (q *DownloadQuery) Status() grimoire.Field[*Download, *DownloadQuery, string] {
+	return grimoire.NewField[*Download, *DownloadQuery, string](q.QueryBuilder, DownloadFieldStatus, wrapDownloadQuery)
+}
+
+// SizeField returns the size field of the query.
+func (q *DownloadQuery) SizeField() grimoire.Field[*Download, *DownloadQuery, int64] {
+	return grimoire.NewField[*Download, *DownloadQuery, int64](q.QueryBuilder, DownloadFieldSize, wrapDownloadQuery)
+}
+
+// Tags returns the tags field of the query.
+func (q *DownloadQuery) Tags() grimoire.Field[*Download, *DownloadQuery, string] {
+	return grimoire.NewField[*Download, *DownloadQuery, string](q.QueryBuilder, DownloadFieldTags, wrapDownloadQuery)
+}
+
+// LimitField returns the limit field of the query.
+func (q *DownloadQuery) LimitField() grimoire.Field[*Download, *DownloadQuery, int] {
+	return grimoire.NewField[*Download, *DownloadQuery, int](q.QueryBuilder, DownloadFieldLimit, wrapDownloadQuery)
+}
+
+// TimestampsFound returns the timestamps.found field of the query.
+func (q *DownloadQuery) TimestampsFound() grimoire.Field[*Download, *DownloadQuery, time.Time] {
+	return grimoire.NewField[*Download, *DownloadQuery, time.Time](q.QueryBuilder, DownloadFieldTimestampsFound, wrapDownloadQuery)
+}
+
+// TimestampsCompleted returns the timestamps.completed field of the query.
+func (q *DownloadQuery) TimestampsCompleted() grimoire.Field[*Download, *DownloadQuery, time.Time] {
+	return grimoire.NewField[*Download, *DownloadQuery, time.Time](q.QueryBuilder, DownloadFieldTimestampsCompleted, wrapDownloadQuery)
+}
+
+// FilesName returns the files.name field of the query.
+func (q *DownloadQuery) FilesName() grimoire.Field[*Download, *DownloadQuery, string] {
+	return grimoire.NewField[*Download, *DownloadQuery, string](q.QueryBuilder, DownloadFieldFilesName, wrapDownloadQuery)
+}
+
+// FilesNum returns the files.num field of the query.
+func (q *DownloadQuery) FilesNum() grimoire.Field[*Download, *DownloadQuery, int] {
+	return grimoire.NewField[*Download, *DownloadQuery, int](q.QueryBuilder, DownloadFieldFilesNum, wrapDownloadQuery)
+}
+
+// BSON paths of the fields of Medium.
+const (
+	MediumFieldID        = "_id"
+	MediumFieldCreatedAt = "created_at"
+	MediumFieldUpdatedAt = "updated_at"
+	MediumFieldTitle     = "title"
+	MediumFieldKind      = "kind"
+)
+
+// MediumIndexes are the indexes declared by the grimoire tags of Medium.
+var MediumIndexes = []mongo.IndexModel{}
+
+// MediumStore is a store of Medium documents, with a typed query builder.
+type MediumStore struct {
+	*grimoire.Store[*Medium]
+}
+
+// NewMediumStore creates a store of Medium documents, see grimoire.New.
+func NewMediumStore(URI, database, collection string) (*MediumStore, error) {
+	s, err := grimoire.New[*Medium](URI, database, collection)
+	if err != nil {
+		return nil, err
+	}
+	return &MediumStore{Store: s}, nil
+}
+
+// Query returns a typed query builder of the store.
+func (s *MediumStore) Query() *MediumQuery {
+	return wrapMediumQuery(s.Store.Query())
+}
+
+// CreateIndexes creates MediumIndexes on the collection of the store.
+func (s *MediumStore) CreateIndexes(ctx context.Context) error {
+	if len(MediumIndexes) == 0 {
+		return nil
+	}
+	_, err := s.Collection.Indexes().CreateMany(ctx, MediumIndexes)
+	return err
+}
+
+// MediumQuery is a query builder of Medium documents, with a method for each field.
+// The methods of grimoire.QueryBuilder return the *grimoire.QueryBuilder, so call the field
+// methods before them in a chain, see grimoire.Field.
+type MediumQuery struct {
+	*grimoire.QueryBuilder[*Medium]
+}
+
+func wrapMediumQuery(q *grimoire.QueryBuilder[*Medium]) *MediumQuery {
+	return &MediumQuery{QueryBuilder: q}
+}
+
+// ID returns the _id field of the query.
+func (q *MediumQuery) ID() grimoire.Field[*Medium, *MediumQuery, primitive.ObjectID] {
+	return grimoire.NewField[*Medium, *MediumQuery, primitive.ObjectID](q.QueryBuilder, MediumFieldID, wrapMediumQuery)
+}
+
+// CreatedAt returns the created_at field of the query.
+func (q *MediumQuery) CreatedAt() grimoire.Field[*Medium, *MediumQuery, time.Time] {
+	return grimoire.NewField[*Medium, *MediumQuery, time.Time](q.QueryBuilder, MediumFieldCreatedAt, wrapMediumQuery)
+}
+
+// UpdatedAt returns the updated_at field of the query.
+func (q *MediumQuery) UpdatedAt() grimoire.Field[*Medium, *MediumQuery, time.Time] {
+	return grimoire.NewField[*Medium, *MediumQuery, time.Time](q.QueryBuilder, MediumFieldUpdatedAt, wrapMediumQuery)
+}
+
+// Title returns the title field of the query.
+func (q *MediumQuery) Title() grimoire.Field[*Medium, *MediumQuery, string] {
+	return grimoire.NewField[*Medium, *MediumQuery, string](q.QueryBuilder, MediumFieldTitle, wrapMediumQuery)
+}
+
+// Kind returns the kind field of the query.
+func (q *MediumQuery) Kind() grimoire.Field[*Medium, *MediumQuery, primitive.Symbol] {
+	return grimoire.NewField[*Medium, *MediumQuery, primitive.Symbol](q.QueryBuilder, MediumFieldKind, wrapMediumQuery)
+}
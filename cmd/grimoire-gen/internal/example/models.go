@@ -0,0 +1,39 @@
+// Package example has models for the tests of grimoire-gen, whose generated code is checked in.
+package example
+
+import (
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dashotv/grimoire"
+)
+
+//go:generate go run ../..
+
+type Download struct {
+	grimoire.Document `bson:",inline"`
+	MediumID          primitive.ObjectID `bson:"medium_id" grimoire:"index"`
+	Status            string             `bson:"status" grimoire:"required,index"`
+	Size              int64              `bson:"size"`
+	Tags              []string           `bson:"tags"`
+	Limit             int                `bson:"limit"`
+	Timestamps        struct {
+		Found     time.Time  `bson:"found" grimoire:"index,desc"`
+		Completed *time.Time `bson:"completed"`
+	} `bson:"timestamps"`
+	Files  []File  `bson:"files"`
+	Medium *Medium `bson:"-"`
+	notes  string
+}
+
+type File struct {
+	Name string `bson:"name"`
+	Num  int    `bson:"num"`
+}
+
+type Medium struct {
+	grimoire.Document `bson:",inline"`
+	Title             string           `bson:"title"`
+	Kind              primitive.Symbol `bson:"kind"`
+}
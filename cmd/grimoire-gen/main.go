@@ -0,0 +1,59 @@
+// Command grimoire-gen generates typed stores and query builders for the models of a package,
+// which are the structs embedding grimoire.Document.
+//
+// For each model, it generates:
+//   - constants with the BSON path of each field, e.g. DownloadFieldStatus = "status"
+//   - a DownloadStore wrapping grimoire.Store[*Download], with its constructor
+//   - a DownloadQuery wrapping grimoire.QueryBuilder[*Download], with a method per field, e.g.
+//     q.Status().Eq("done")
+//   - the indexes declared with `grimoire:"index"` tags, and DownloadStore.CreateIndexes
+//
+// Usage, in a file of the package of the models:
+//
+//	//go:generate go run github.com/dashotv/grimoire/cmd/grimoire-gen
+//
+// Flags:
+//
+//	-type   comma-separated models to generate, all models by default
+//	-output file to write, grimoire_gen.go by default
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+func main() {
+	types := flag.String("type", "", "comma-separated models to generate, all models by default")
+	output := flag.String("output", "grimoire_gen.go", "file to write, relative to the package directory")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: grimoire-gen [-type Model,...] [-output file] [directory]")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	dir := "."
+	if flag.NArg() > 0 {
+		dir = flag.Arg(0)
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(*types, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			names = append(names, name)
+		}
+	}
+
+	src, err := generate(dir, names)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "grimoire-gen: %s\n", err)
+		os.Exit(1)
+	}
+	if err := os.WriteFile(filepath.Join(dir, *output), src, 0o644); err != nil {
+		fmt.Fprintf(os.Stderr, "grimoire-gen: %s\n", err)
+		os.Exit(1)
+	}
+}
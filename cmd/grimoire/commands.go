@@ -0,0 +1,175 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"text/tabwriter"
+
+	"go.mongodb.org/mongo-driver/bson"
+
+	"github.com/dashotv/grimoire"
+)
+
+func runCollections(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("collections")
+	if _, err := parseArgs(fs, args); err != nil {
+		return err
+	}
+	s, err := c.store("")
+	if err != nil {
+		return err
+	}
+
+	names, err := s.Database.ListCollectionNames(ctx, bson.M{})
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(w, "NAME\tCOUNT\tSIZE\tSTORAGE\tINDEXES\tINDEX SIZE\t")
+	for _, name := range names {
+		stats := struct {
+			Count          int64 `bson:"count"`
+			Size           int64 `bson:"size"`
+			StorageSize    int64 `bson:"storageSize"`
+			Indexes        int64 `bson:"nindexes"`
+			TotalIndexSize int64 `bson:"totalIndexSize"`
+		}{}
+		// views have no stats
+		if err := s.Database.RunCommand(ctx, bson.D{{Key: "collStats", Value: name}}).Decode(&stats); err != nil {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t-\t\n", name)
+			continue
+		}
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\t%d\t%s\t\n", name, stats.Count,
+			bytes(stats.Size), bytes(stats.StorageSize), stats.Indexes, bytes(stats.TotalIndexSize))
+	}
+	return w.Flush()
+}
+
+func runFind(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("find")
+	qf := &queryFlags{}
+	qf.register(fs, 25)
+	count := fs.Bool("count", false, "print the number of matching documents")
+	format := fs.String("format", string(grimoire.FormatJSONLines), "jsonl, extjson or csv")
+	columns := fs.String("columns", "", "comma-separated CSV columns, the fields of the first document by default")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	collection, err := collectionArg(fs, args)
+	if err != nil {
+		return err
+	}
+	opts, err := exportOptions(*format, *columns)
+	if err != nil {
+		return err
+	}
+	s, err := c.store(collection)
+	if err != nil {
+		return err
+	}
+	q, err := qf.build(s)
+	if err != nil {
+		return err
+	}
+	q = q.WithContext(ctx)
+
+	if *count {
+		n, err := q.Count()
+		if err != nil {
+			return err
+		}
+		fmt.Fprintln(c.out, n)
+		return nil
+	}
+
+	_, err = q.Export(c.out, opts)
+	return err
+}
+
+func runExplain(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("explain")
+	qf := &queryFlags{}
+	qf.register(fs, 25)
+	verbosity := fs.String("verbosity", grimoire.ExplainExecutionStats, "queryPlanner, executionStats or allPlansExecution")
+	op := fs.String("op", "find", "operation to explain: find, count or delete")
+	raw := fs.Bool("raw", false, "print the full output of the explain command")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	collection, err := collectionArg(fs, args)
+	if err != nil {
+		return err
+	}
+	s, err := c.store(collection)
+	if err != nil {
+		return err
+	}
+	q, err := qf.build(s)
+	if err != nil {
+		return err
+	}
+	q = q.WithContext(ctx)
+
+	var e *grimoire.Explanation
+	switch *op {
+	case "find":
+		e, err = q.Explain(*verbosity)
+	case "count":
+		e, err = q.ExplainCount(*verbosity)
+	case "delete":
+		e, err = q.ExplainDeleteMany(*verbosity)
+	default:
+		return fmt.Errorf("unknown operation %q", *op)
+	}
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintln(c.out, q.String())
+	fmt.Fprintln(c.out, e.String())
+	if *raw {
+		return writeDocument(c.out, e.Raw, false)
+	}
+	return nil
+}
+
+func runMigrations(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("migrations")
+	collection := fs.String("collection", "migrations", "collection recording the applied migrations")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	if len(args) != 0 {
+		fs.Usage()
+		return errors.New("migrations takes no arguments, migrations are run by the programs registering them")
+	}
+	s, err := c.store("")
+	if err != nil {
+		return err
+	}
+
+	m := s.Migrator()
+	m.SetCollection(*collection)
+	return m.Command(ctx, []string{"status"}, c.out)
+}
+
+// bytes formats a size in bytes for humans.
+func bytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%dB", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f%cB", float64(n)/float64(div), "KMGTPE"[exp])
+}
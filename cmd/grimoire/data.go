@@ -0,0 +1,121 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/dashotv/grimoire"
+)
+
+func runExport(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("export")
+	qf := &queryFlags{}
+	qf.register(fs, 0)
+	output := fs.String("o", "", "output file, standard output by default")
+	format := fs.String("format", string(grimoire.FormatJSONLines), "jsonl, extjson (canonical Extended JSON, which keeps the BSON types) or csv")
+	columns := fs.String("columns", "", "comma-separated CSV columns, the fields of the first document by default")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	collection, err := collectionArg(fs, args)
+	if err != nil {
+		return err
+	}
+	opts, err := exportOptions(*format, *columns)
+	if err != nil {
+		return err
+	}
+	s, err := c.store(collection)
+	if err != nil {
+		return err
+	}
+	q, err := qf.build(s)
+	if err != nil {
+		return err
+	}
+
+	w := c.out
+	if *output != "" {
+		f, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	n, err := q.WithContext(ctx).Export(w, opts)
+	if err != nil {
+		return err
+	}
+	if *output != "" {
+		fmt.Fprintf(os.Stderr, "exported %d documents\n", n)
+	}
+	return nil
+}
+
+func runImport(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("import")
+	input := fs.String("i", "", "input file, standard input by default")
+	format := fs.String("format", string(grimoire.FormatJSONLines), "jsonl, extjson or csv")
+	upsert := fs.Bool("upsert", false, "replace the documents with the same _id")
+	batch := fs.Int("batch", 1000, "number of documents written at a time")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	collection, err := collectionArg(fs, args)
+	if err != nil {
+		return err
+	}
+	f, err := grimoire.ParseFormat(*format)
+	if err != nil {
+		return err
+	}
+	s, err := c.store(collection)
+	if err != nil {
+		return err
+	}
+
+	var r io.Reader = os.Stdin
+	if *input != "" {
+		f, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		r = f
+	}
+
+	res, err := s.ImportWithContext(ctx, r, grimoire.ImportOptions{Format: f, BatchSize: *batch, Upsert: *upsert})
+	if res != nil {
+		for _, lerr := range res.Errors {
+			fmt.Fprintln(os.Stderr, lerr)
+		}
+		fmt.Fprintf(os.Stderr, "read %d documents: %d inserted, %d replaced, %d errors\n",
+			res.Read, res.Inserted, res.Replaced, len(res.Errors))
+	}
+	if err != nil {
+		return err
+	}
+	if len(res.Errors) > 0 {
+		return fmt.Errorf("%d documents were not imported", len(res.Errors))
+	}
+	return nil
+}
+
+// exportOptions returns the options of an export in format, with the comma-separated columns.
+func exportOptions(format, columns string) (grimoire.ExportOptions, error) {
+	f, err := grimoire.ParseFormat(format)
+	if err != nil {
+		return grimoire.ExportOptions{}, err
+	}
+	opts := grimoire.ExportOptions{Format: f}
+	if list := splitList(columns); len(list) > 0 {
+		opts.Columns = list
+	}
+	return opts, nil
+}
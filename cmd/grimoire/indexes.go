@@ -0,0 +1,139 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+
+	"github.com/dashotv/grimoire"
+)
+
+// index is an index of a collection, as returned by listIndexes.
+type index struct {
+	Name   string `bson:"name"`
+	Key    bson.D `bson:"key"`
+	Unique bool   `bson:"unique,omitempty"`
+	Sparse bool   `bson:"sparse,omitempty"`
+}
+
+func runIndexes(ctx context.Context, c *cli, args []string) error {
+	fs := commandFlags("indexes")
+	spec := fs.String("sync", "", "indexes to create, separated by semicolons, each a comma-separated list of field[:asc|desc|text]")
+	drop := fs.Bool("drop", false, "with -sync, drop the indexes that are not in the spec")
+	dryRun := fs.Bool("dry-run", false, "with -sync, print the changes without making them")
+	args, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+	collection, err := collectionArg(fs, args)
+	if err != nil {
+		return err
+	}
+	s, err := c.store(collection)
+	if err != nil {
+		return err
+	}
+	view := s.Collection.Indexes()
+
+	cursor, err := view.List(ctx)
+	if err != nil {
+		return err
+	}
+	existing := []index{}
+	if err := cursor.All(ctx, &existing); err != nil {
+		return err
+	}
+
+	if *spec == "" {
+		w := tabwriter.NewWriter(c.out, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(w, "NAME\tKEYS\tOPTIONS")
+		for _, idx := range existing {
+			options := []string{}
+			if idx.Unique {
+				options = append(options, "unique")
+			}
+			if idx.Sparse {
+				options = append(options, "sparse")
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\n", idx.Name, formatKeys(idx.Key), strings.Join(options, ","))
+		}
+		return w.Flush()
+	}
+
+	wanted, err := grimoire.ParseIndexSpec(*spec)
+	if err != nil {
+		return err
+	}
+	create, remove := diffIndexes(existing, wanted)
+	if !*drop {
+		remove = nil
+	}
+
+	for _, keys := range create {
+		fmt.Fprintf(c.out, "create %s\n", formatKeys(keys))
+		if *dryRun {
+			continue
+		}
+		if _, err := view.CreateOne(ctx, mongo.IndexModel{Keys: keys}); err != nil {
+			return err
+		}
+	}
+	for _, name := range remove {
+		fmt.Fprintf(c.out, "drop %s\n", name)
+		if *dryRun {
+			continue
+		}
+		if _, err := view.DropOne(ctx, name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// diffIndexes returns the wanted indexes that do not exist, and the names of the existing indexes
+// that are not wanted. The _id index is never removed.
+func diffIndexes(existing []index, wanted []bson.D) ([]bson.D, []string) {
+	create := []bson.D{}
+	for _, keys := range wanted {
+		found := false
+		for _, idx := range existing {
+			if grimoire.SameIndexKeys(idx.Key, keys) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			create = append(create, keys)
+		}
+	}
+
+	remove := []string{}
+	for _, idx := range existing {
+		if idx.Name == "_id_" {
+			continue
+		}
+		found := false
+		for _, keys := range wanted {
+			if grimoire.SameIndexKeys(idx.Key, keys) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			remove = append(remove, idx.Name)
+		}
+	}
+	return create, remove
+}
+
+func formatKeys(keys bson.D) string {
+	list := make([]string, 0, len(keys))
+	for _, k := range keys {
+		list = append(list, fmt.Sprintf("%s:%v", k.Key, k.Value))
+	}
+	return strings.Join(list, ",")
+}
@@ -0,0 +1,26 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestDiffIndexes(t *testing.T) {
+	existing := []index{
+		{Name: "_id_", Key: bson.D{{Key: "_id", Value: int32(1)}}},
+		{Name: "status_1", Key: bson.D{{Key: "status", Value: int32(1)}}},
+		{Name: "title_1", Key: bson.D{{Key: "title", Value: int32(1)}}},
+		{Name: "name_text", Key: bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}},
+	}
+	wanted := []bson.D{
+		{{Key: "status", Value: 1}},
+		{{Key: "created_at", Value: -1}},
+		{{Key: "name", Value: "text"}},
+	}
+
+	create, remove := diffIndexes(existing, wanted)
+	assert.Equal(t, []bson.D{{{Key: "created_at", Value: -1}}}, create)
+	assert.Equal(t, []string{"title_1"}, remove)
+}
@@ -0,0 +1,161 @@
+// Command grimoire runs day-to-day operations on a MongoDB database: listing collections,
+// finding documents, managing indexes, explaining queries, checking migrations, and exporting
+// and importing data.
+//
+// Usage:
+//
+//	grimoire [-uri URI] -db DATABASE <command> [arguments] [flags]
+//
+// The URI and database default to the GRIMOIRE_URI and GRIMOIRE_DB environment variables.
+// Filters are written as Extended JSON, e.g. -filter '{"status": "done"}'.
+//
+// Migrations are Go functions, so they are run by the programs registering them, with
+// grimoire.Migrator.Command, which this tool cannot do.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/dashotv/grimoire"
+)
+
+// command is a subcommand of the tool.
+type command struct {
+	name  string
+	usage string
+	help  string
+	run   func(ctx context.Context, c *cli, args []string) error
+}
+
+// commands is set in init, as the commands refer to it for their usage.
+var commands []command
+
+func init() {
+	commands = []command{
+		{"collections", "collections", "list collections with document counts and sizes", runCollections},
+		{"find", "find <collection> [-filter JSON] [-sort FIELDS] [-limit N] [-skip N] [-select FIELDS] [-count] [-format jsonl|extjson|csv]", "find documents and print them", runFind},
+		{"indexes", "indexes <collection> [-sync SPEC] [-drop] [-dry-run]", "list indexes, or sync them with a spec such as \"status;created_at:desc;name,age:-1\"", runIndexes},
+		{"explain", "explain <collection> [-filter JSON] [-sort FIELDS] [-limit N] [-verbosity V] [-op find|count|delete]", "explain a query", runExplain},
+		{"migrations", "migrations [-collection NAME]", "list the applied migrations", runMigrations},
+		{"export", "export <collection> [-filter JSON] [-o FILE] [-format jsonl|extjson|csv] [-columns FIELDS]", "export documents as JSON lines or CSV", runExport},
+		{"import", "import <collection> [-i FILE] [-format jsonl|extjson|csv] [-upsert] [-batch N]", "import documents from JSON lines or CSV", runImport},
+	}
+}
+
+// cli holds the global options of the tool.
+type cli struct {
+	uri string
+	db  string
+	out io.Writer
+}
+
+// store returns a store for collection, decoding documents of any shape.
+func (c *cli) store(collection string) (*grimoire.Store[record], error) {
+	if c.db == "" {
+		return nil, errors.New("no database, use -db or GRIMOIRE_DB")
+	}
+	return grimoire.New[record](c.uri, c.db, collection)
+}
+
+func main() {
+	c := &cli{out: os.Stdout}
+
+	fs := flag.NewFlagSet("grimoire", flag.ExitOnError)
+	fs.StringVar(&c.uri, "uri", env("GRIMOIRE_URI", "mongodb://localhost:27017"), "MongoDB connection string")
+	fs.StringVar(&c.db, "db", os.Getenv("GRIMOIRE_DB"), "database name")
+	fs.Usage = func() { usage(fs) }
+	_ = fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		usage(fs)
+		os.Exit(2)
+	}
+
+	name := fs.Arg(0)
+	for _, cmd := range commands {
+		if cmd.name != name {
+			continue
+		}
+		ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+		err := cmd.run(ctx, c, fs.Args()[1:])
+		stop()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "grimoire %s: %s\n", name, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	fmt.Fprintf(os.Stderr, "grimoire: unknown command %q\n", name)
+	usage(fs)
+	os.Exit(2)
+}
+
+func usage(fs *flag.FlagSet) {
+	out := fs.Output()
+	fmt.Fprintln(out, "usage: grimoire [-uri URI] -db DATABASE <command> [arguments] [flags]")
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "commands:")
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	for _, cmd := range commands {
+		fmt.Fprintf(w, "  %s\t%s\n", cmd.name, cmd.help)
+	}
+	w.Flush()
+	fmt.Fprintln(out)
+	fmt.Fprintln(out, "flags:")
+	fs.PrintDefaults()
+}
+
+// parseArgs parses the flags of fs, which may come before or after the positional arguments,
+// and returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := []string{}
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		if fs.NArg() == 0 {
+			return positional, nil
+		}
+		positional = append(positional, fs.Arg(0))
+		args = fs.Args()[1:]
+	}
+}
+
+// commandFlags returns the flag set of the command name.
+func commandFlags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	for _, cmd := range commands {
+		if cmd.name == name {
+			fs.Usage = func() {
+				fmt.Fprintf(fs.Output(), "usage: grimoire %s\n", cmd.usage)
+				fs.PrintDefaults()
+			}
+		}
+	}
+	return fs
+}
+
+// collectionArg returns the single collection argument.
+func collectionArg(fs *flag.FlagSet, args []string) (string, error) {
+	if len(args) != 1 {
+		fs.Usage()
+		return "", errors.New("expected one collection")
+	}
+	return args[0], nil
+}
+
+func env(key, def string) string {
+	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
+		return v
+	}
+	return def
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dashotv/grimoire"
+)
+
+// record is a document of any shape.
+type record bson.M
+
+func (r record) PrepareID(id interface{}) (interface{}, error) {
+	if s, ok := id.(string); ok {
+		if oid, err := primitive.ObjectIDFromHex(s); err == nil {
+			return oid, nil
+		}
+	}
+	return id, nil
+}
+
+func (r record) GetID() interface{} {
+	if id, ok := r["_id"]; ok {
+		return id
+	}
+	return primitive.NilObjectID
+}
+
+func (r record) SetID(id interface{}) {
+	r["_id"] = id
+}
+
+// queryFlags are the flags describing a query.
+type queryFlags struct {
+	filter string
+	sort   string
+	fields string
+	limit  int
+	skip   int
+}
+
+func (f *queryFlags) register(fs *flag.FlagSet, limit int) {
+	fs.StringVar(&f.filter, "filter", "", "filter as Extended JSON")
+	fs.StringVar(&f.sort, "sort", "", "comma-separated sort fields, prefixed with - for descending")
+	fs.StringVar(&f.fields, "select", "", "comma-separated fields to return")
+	fs.IntVar(&f.limit, "limit", limit, "maximum number of documents, 0 for no limit")
+	fs.IntVar(&f.skip, "skip", 0, "number of documents to skip")
+}
+
+// build returns the query of s described by the flags.
+func (f *queryFlags) build(s *grimoire.Store[record]) (*grimoire.QueryBuilder[record], error) {
+	filter, err := parseFilter(f.filter)
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.Query().WhereRaw(filter).Limit(f.limit).Skip(f.skip)
+	for _, field := range splitList(f.sort) {
+		if strings.HasPrefix(field, "-") {
+			q = q.Desc(field[1:])
+		} else {
+			q = q.Asc(strings.TrimPrefix(field, "+"))
+		}
+	}
+	if fields := splitList(f.fields); len(fields) > 0 {
+		q = q.Select(fields...)
+	}
+	return q, nil
+}
+
+// parseFilter parses an Extended JSON filter, canonical or relaxed.
+func parseFilter(s string) (bson.M, error) {
+	filter := bson.M{}
+	if strings.TrimSpace(s) == "" {
+		return filter, nil
+	}
+	if err := bson.UnmarshalExtJSON([]byte(s), false, &filter); err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return filter, nil
+}
+
+// writeDocument writes doc to w as a line of Extended JSON.
+func writeDocument(w io.Writer, doc interface{}, canonical bool) error {
+	data, err := bson.MarshalExtJSON(doc, canonical, false)
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+	_, err = w.Write(data)
+	return err
+}
+
+func splitList(s string) []string {
+	list := []string{}
+	for _, v := range strings.Split(s, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			list = append(list, v)
+		}
+	}
+	return list
+}
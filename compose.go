@@ -0,0 +1,115 @@
+package grimoire
+
+import (
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Scope adds clauses or options to a query, and returns the resulting query. Scopes should use
+// the query returned by each QueryBuilder method, so they also work on immutable queries.
+//
+// Example:
+//
+//	func Active(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+//		return q.In("status", []string{"searching", "loading", "downloading"})
+//	}
+type Scope[T mgm.Model] func(q *QueryBuilder[T]) *QueryBuilder[T]
+
+// Clone returns a mutable copy of the query. Changes to the copy do not affect the query, and
+// changes to the query do not affect the copy.
+//
+// Example:
+//
+//	recent := q.Clone().Desc("created_at").Limit(10)
+func (q *QueryBuilder[T]) Clone() *QueryBuilder[T] {
+	c := q.clone()
+	c.immutable = false
+	return c
+}
+
+// Immutable returns a copy of the query in copy-on-write mode. Every method of an immutable
+// query leaves it unchanged and returns a new immutable query with the change applied, so a
+// base query can be shared between requests and goroutines.
+//
+// Example:
+//
+//	base := s.Query().Where("status", "done").Immutable()
+//	movies := base.Where("_type", "Movie") // base is unchanged
+func (q *QueryBuilder[T]) Immutable() *QueryBuilder[T] {
+	c := q.clone()
+	c.immutable = true
+	return c
+}
+
+// clone returns a copy of the query, in the same mode. The clauses themselves are shared, since
+// they are never changed once added.
+func (q *QueryBuilder[T]) clone() *QueryBuilder[T] {
+	c := *q
+	c.values = append(make([]bson.M, 0, len(q.values)), q.values...)
+	c.sort = append(bson.D{}, q.sort...)
+	c.without = append([]string(nil), q.without...)
+	c.preloads = append([]string(nil), q.preloads...)
+	if q.projection != nil {
+		c.projection = append(bson.D{}, q.projection...)
+	}
+	if q.collation != nil {
+		collation := *q.collation
+		c.collation = &collation
+	}
+	return &c
+}
+
+// mutable returns the query to apply a change to: the query itself, or a copy of it when the
+// query is immutable.
+func (q *QueryBuilder[T]) mutable() *QueryBuilder[T] {
+	if q.immutable {
+		return q.clone()
+	}
+	return q
+}
+
+// add adds clauses to the query.
+func (q *QueryBuilder[T]) add(values ...bson.M) *QueryBuilder[T] {
+	q = q.mutable()
+	q.values = append(q.values, values...)
+	return q
+}
+
+// Merge adds the clauses and sort fields of other to the query. The limit, skip, projection and
+// options of the query are kept.
+//
+// Example:
+//
+//	Merge(s.Query().Where("_type", "Movie").Desc("created_at"))
+func (q *QueryBuilder[T]) Merge(other *QueryBuilder[T]) *QueryBuilder[T] {
+	q = q.add(other.values...)
+	q.sort = append(q.sort, other.sort...)
+	return q
+}
+
+// When applies scope to the query if the condition is true. Unlike If, the scope can add any
+// clause or option.
+//
+// Example:
+//
+//	When(status != "", func(q *QueryBuilder[T]) *QueryBuilder[T] {
+//		return q.Where("status", status).Desc("updated_at")
+//	})
+func (q *QueryBuilder[T]) When(cond bool, scope Scope[T]) *QueryBuilder[T] {
+	if !cond {
+		return q
+	}
+	return scope(q)
+}
+
+// Apply applies scopes to the query, in order.
+//
+// Example:
+//
+//	Apply(Active, Recent)
+func (q *QueryBuilder[T]) Apply(scopes ...Scope[T]) *QueryBuilder[T] {
+	for _, scope := range scopes {
+		q = scope(q)
+	}
+	return q
+}
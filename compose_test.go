@@ -0,0 +1,100 @@
+package grimoire
+
+import (
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestQueryBuilder_Clone(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().Where("status", "done").Desc("created_at").Select("title").EqualFold("title", "x")
+	c := q.Clone().Where("_type", "Movie").Asc("title").Exclude("paths").Limit(10)
+	c.collation.Locale = "fr"
+
+	assert.Len(t, q.values, 2)
+	assert.Len(t, c.values, 3)
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}}, q.sort)
+	assert.Equal(t, bson.D{{Key: "title", Value: 1}}, q.projection)
+	assert.Equal(t, int64(25), q.limit)
+	assert.Equal(t, "en", q.collation.Locale)
+	assert.Equal(t, int64(10), c.limit)
+}
+
+func TestQueryBuilder_Immutable(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	base := s.Query().Where("status", "done").Immutable()
+	base.Where("_type", "Movie").Limit(5)
+	base.Or(func(q *QueryBuilder[*Download]) {
+		q.Where("a", 1).Where("b", 2)
+	}).Desc("created_at")
+	assert.Len(t, base.values, 1)
+	assert.Empty(t, base.sort)
+	assert.Equal(t, int64(25), base.limit)
+
+	movies := base.Where("_type", "Movie").Desc("created_at").Limit(5)
+	assert.True(t, movies.immutable)
+	assert.Len(t, movies.values, 2)
+	assert.Equal(t, int64(5), movies.limit)
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			q := base.Where("n", i).Skip(i)
+			assert.Len(t, q.values, 2)
+			assert.Equal(t, bson.M{"n": bson.M{"$eq": i}}, q.values[1])
+		}(i)
+	}
+	wg.Wait()
+	assert.Len(t, base.values, 1)
+
+	m := base.Clone()
+	m.Where("_type", "Series")
+	assert.False(t, m.immutable)
+	assert.Len(t, m.values, 2)
+}
+
+func TestQueryBuilder_Compose(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	active := func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.In("status", []string{"searching", "loading"})
+	}
+	recent := func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.Desc("created_at").Limit(10)
+	}
+
+	q := s.Query().Apply(active, recent)
+	assert.Equal(t, []bson.M{{"status": bson.M{"$in": []string{"searching", "loading"}}}}, q.values)
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}}, q.sort)
+	assert.Equal(t, int64(10), q.limit)
+
+	q = s.Query().When(false, active).When(true, func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.GreaterThan("size", 10).Asc("title")
+	})
+	assert.Equal(t, []bson.M{{"size": bson.M{"$gt": 10}}}, q.values)
+	assert.Equal(t, bson.D{{Key: "title", Value: 1}}, q.sort)
+
+	base := s.Query().Where("status", "done").Limit(5).Immutable()
+	merged := base.Merge(s.Query().Where("_type", "Movie").Desc("created_at").Limit(50))
+	assert.Equal(t, []bson.M{
+		{"status": bson.M{"$eq": "done"}},
+		{"_type": bson.M{"$eq": "Movie"}},
+	}, merged.values)
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}}, merged.sort)
+	assert.Equal(t, int64(5), merged.limit)
+	assert.Len(t, base.values, 1)
+	assert.Empty(t, base.sort)
+}
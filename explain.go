@@ -0,0 +1,256 @@
+package grimoire
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// Explain verbosity modes, see https://www.mongodb.com/docs/manual/reference/command/explain/
+const (
+	// ExplainQueryPlanner returns the winning plan without running the query.
+	ExplainQueryPlanner = "queryPlanner"
+	// ExplainExecutionStats runs the winning plan and returns its execution statistics.
+	ExplainExecutionStats = "executionStats"
+	// ExplainAllPlansExecution runs all candidate plans and returns their execution statistics.
+	ExplainAllPlansExecution = "allPlansExecution"
+)
+
+// Explanation is a summary of the output of the explain command. The execution statistics are
+// only set when the verbosity is ExplainExecutionStats or ExplainAllPlansExecution.
+type Explanation struct {
+	// Stage is the root stage of the winning plan, e.g. FETCH or COLLSCAN.
+	Stage string
+	// Stages lists every stage of the winning plan, from the root down.
+	Stages []string
+	// Indexes lists the names of the indexes used by the winning plan.
+	Indexes []string
+	// CollectionScan is true when the winning plan scans the whole collection.
+	CollectionScan bool
+	// Returned is the number of documents returned.
+	Returned int64
+	// DocsExamined is the number of documents examined.
+	DocsExamined int64
+	// KeysExamined is the number of index keys examined.
+	KeysExamined int64
+	// ExecutionTime is the time the server spent executing the query.
+	ExecutionTime time.Duration
+	// Raw is the full output of the explain command.
+	Raw bson.M
+}
+
+// IndexUsed returns true when the winning plan uses at least one index.
+func (e *Explanation) IndexUsed() bool {
+	return len(e.Indexes) > 0
+}
+
+func (e *Explanation) String() string {
+	index := "none"
+	if e.IndexUsed() {
+		index = strings.Join(e.Indexes, ",")
+	}
+	return fmt.Sprintf("stage=%s index=%s returned=%d docs_examined=%d keys_examined=%d time=%s",
+		strings.Join(e.Stages, "<-"), index, e.Returned, e.DocsExamined, e.KeysExamined, e.ExecutionTime)
+}
+
+// Explain explains the query as it would be executed by Run.
+// NOTE: verbosity should be one of ExplainQueryPlanner, ExplainExecutionStats or ExplainAllPlansExecution.
+//
+// Example:
+//
+//	Explain(ExplainExecutionStats)
+func (q *QueryBuilder[T]) Explain(verbosity string) (*Explanation, error) {
+	return q.explain(q.findCommand(), verbosity)
+}
+
+// ExplainCount explains the query as it would be executed by Count, which runs an aggregate
+// pipeline counting the matching documents.
+// NOTE: verbosity should be one of ExplainQueryPlanner, ExplainExecutionStats or ExplainAllPlansExecution.
+func (q *QueryBuilder[T]) ExplainCount(verbosity string) (*Explanation, error) {
+	return q.explain(q.countCommand(), verbosity)
+}
+
+// ExplainDeleteMany explains the query as it would be executed by DeleteMany. No documents
+// are deleted.
+// NOTE: verbosity should be one of ExplainQueryPlanner, ExplainExecutionStats or ExplainAllPlansExecution.
+func (q *QueryBuilder[T]) ExplainDeleteMany(verbosity string) (*Explanation, error) {
+	del := bson.D{
+		{Key: "q", Value: q.filter()},
+		{Key: "limit", Value: 0},
+	}
+	if q.collation != nil {
+		del = append(del, bson.E{Key: "collation", Value: q.collation.ToDocument()})
+	}
+	if q.hint != nil {
+		del = append(del, bson.E{Key: "hint", Value: q.hint})
+	}
+	cmd := bson.D{
+		{Key: "delete", Value: q.store.Collection.Name()},
+		{Key: "deletes", Value: bson.A{del}},
+	}
+	return q.explain(cmd, verbosity)
+}
+
+func (q *QueryBuilder[T]) findCommand() bson.D {
+	cmd := bson.D{
+		{Key: "find", Value: q.store.Collection.Name()},
+		{Key: "filter", Value: q.filter()},
+	}
+	if len(q.sort) > 0 {
+		cmd = append(cmd, bson.E{Key: "sort", Value: q.sort})
+	}
+	if len(q.projection) > 0 {
+		cmd = append(cmd, bson.E{Key: "projection", Value: q.projection})
+	}
+	if q.skip > 0 {
+		cmd = append(cmd, bson.E{Key: "skip", Value: q.skip})
+	}
+	if q.limit > 0 {
+		cmd = append(cmd, bson.E{Key: "limit", Value: q.limit})
+	}
+	if q.collation != nil {
+		cmd = append(cmd, bson.E{Key: "collation", Value: q.collation.ToDocument()})
+	}
+	if q.hint != nil {
+		cmd = append(cmd, bson.E{Key: "hint", Value: q.hint})
+	}
+	if q.maxTime > 0 {
+		cmd = append(cmd, bson.E{Key: "maxTimeMS", Value: q.maxTime.Milliseconds()})
+	}
+	if q.allowDiskUse {
+		cmd = append(cmd, bson.E{Key: "allowDiskUse", Value: true})
+	}
+	if q.comment != "" {
+		cmd = append(cmd, bson.E{Key: "comment", Value: q.comment})
+	}
+	return cmd
+}
+
+// countCommand returns the aggregate command run by CountDocuments with the count options.
+func (q *QueryBuilder[T]) countCommand() bson.D {
+	o := q.countOptions()
+	pipeline := bson.A{bson.D{{Key: "$match", Value: q.filter()}}}
+	if o.Skip != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$skip", Value: *o.Skip}})
+	}
+	if o.Limit != nil {
+		pipeline = append(pipeline, bson.D{{Key: "$limit", Value: *o.Limit}})
+	}
+	pipeline = append(pipeline, bson.D{{Key: "$group", Value: bson.D{
+		{Key: "_id", Value: 1},
+		{Key: "n", Value: bson.D{{Key: "$sum", Value: 1}}},
+	}}})
+
+	cmd := bson.D{
+		{Key: "aggregate", Value: q.store.Collection.Name()},
+		{Key: "pipeline", Value: pipeline},
+		{Key: "cursor", Value: bson.D{}},
+	}
+	if o.Collation != nil {
+		cmd = append(cmd, bson.E{Key: "collation", Value: o.Collation.ToDocument()})
+	}
+	if o.Hint != nil {
+		cmd = append(cmd, bson.E{Key: "hint", Value: o.Hint})
+	}
+	if o.MaxTime != nil {
+		cmd = append(cmd, bson.E{Key: "maxTimeMS", Value: o.MaxTime.Milliseconds()})
+	}
+	if o.Comment != nil {
+		cmd = append(cmd, bson.E{Key: "comment", Value: *o.Comment})
+	}
+	return cmd
+}
+
+func (q *QueryBuilder[T]) explain(cmd bson.D, verbosity string) (*Explanation, error) {
+	raw := bson.M{}
+	err := q.store.Database.RunCommand(q.context(), bson.D{
+		{Key: "explain", Value: cmd},
+		{Key: "verbosity", Value: verbosity},
+	}).Decode(&raw)
+	if err != nil {
+		return nil, err
+	}
+	return parseExplanation(raw), nil
+}
+
+// parseExplanation summarizes the output of the explain command.
+func parseExplanation(raw bson.M) *Explanation {
+	e := &Explanation{Raw: raw}
+
+	// aggregates that are not pushed down to the query engine explain their first stage
+	summary := raw
+	if _, ok := raw["queryPlanner"]; !ok {
+		if stages, ok := raw["stages"].(bson.A); ok && len(stages) > 0 {
+			if first, ok := stages[0].(bson.M); ok {
+				if cursor, ok := first["$cursor"].(bson.M); ok {
+					summary = cursor
+				}
+			}
+		}
+	}
+
+	if planner, ok := summary["queryPlanner"].(bson.M); ok {
+		if plan, ok := planner["winningPlan"].(bson.M); ok {
+			// the slot based engine nests the plan
+			if qp, ok := plan["queryPlan"].(bson.M); ok {
+				plan = qp
+			}
+			e.walkPlan(plan)
+		}
+	}
+	if len(e.Stages) > 0 {
+		e.Stage = e.Stages[0]
+	}
+
+	if stats, ok := summary["executionStats"].(bson.M); ok {
+		e.Returned = toInt64(stats["nReturned"])
+		e.DocsExamined = toInt64(stats["totalDocsExamined"])
+		e.KeysExamined = toInt64(stats["totalKeysExamined"])
+		e.ExecutionTime = time.Duration(toInt64(stats["executionTimeMillis"])) * time.Millisecond
+	}
+
+	return e
+}
+
+func (e *Explanation) walkPlan(plan bson.M) {
+	if stage, ok := plan["stage"].(string); ok {
+		e.Stages = append(e.Stages, stage)
+		if stage == "COLLSCAN" {
+			e.CollectionScan = true
+		}
+	}
+	if name, ok := plan["indexName"].(string); ok {
+		e.Indexes = append(e.Indexes, name)
+	}
+	if input, ok := plan["inputStage"].(bson.M); ok {
+		e.walkPlan(input)
+	}
+	for _, key := range []string{"inputStages", "shards"} {
+		if inputs, ok := plan[key].(bson.A); ok {
+			for _, input := range inputs {
+				if m, ok := input.(bson.M); ok {
+					if wp, ok := m["winningPlan"].(bson.M); ok {
+						m = wp
+					}
+					e.walkPlan(m)
+				}
+			}
+		}
+	}
+}
+
+func toInt64(v interface{}) int64 {
+	switch n := v.(type) {
+	case int32:
+		return int64(n)
+	case int64:
+		return n
+	case float64:
+		return int64(n)
+	case int:
+		return int64(n)
+	}
+	return 0
+}
@@ -0,0 +1,105 @@
+package grimoire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestQueryBuilder_Explain(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	require.NoError(t, err)
+
+	e, err := s.Query().Where("status", "done").Desc("created_at").Explain(ExplainExecutionStats)
+	require.NoError(t, err)
+	assert.NotEmpty(t, e.Stage)
+	assert.LessOrEqual(t, e.Returned, int64(25))
+
+	e, err = s.Query().Where("status", "done").ExplainCount(ExplainQueryPlanner)
+	require.NoError(t, err)
+	assert.NotEmpty(t, e.Stage)
+
+	e, err = s.Query().Where("status", "nope").ExplainDeleteMany(ExplainExecutionStats)
+	require.NoError(t, err)
+	assert.NotEmpty(t, e.Stage)
+}
+
+func TestQueryBuilder_ExplainCountCommand(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	require.NoError(t, err)
+
+	cmd := s.Query().Where("status", "done").Hint("status_1").Comment("report").countCommand()
+	assert.Equal(t, bson.D{
+		{Key: "aggregate", Value: "downloads"},
+		{Key: "pipeline", Value: bson.A{
+			bson.D{{Key: "$match", Value: bson.M{"$and": []bson.M{{"status": bson.M{"$eq": "done"}}}}}},
+			bson.D{{Key: "$group", Value: bson.D{
+				{Key: "_id", Value: 1},
+				{Key: "n", Value: bson.D{{Key: "$sum", Value: 1}}},
+			}}},
+		}},
+		{Key: "cursor", Value: bson.D{}},
+		{Key: "hint", Value: "status_1"},
+		{Key: "comment", Value: "report"},
+	}, cmd)
+}
+
+func TestParseExplanation(t *testing.T) {
+	e := parseExplanation(bson.M{
+		"queryPlanner": bson.M{
+			"winningPlan": bson.M{
+				"queryPlan": bson.M{
+					"stage": "LIMIT",
+					"inputStage": bson.M{
+						"stage": "FETCH",
+						"inputStage": bson.M{
+							"stage":     "IXSCAN",
+							"indexName": "status_1_created_at_-1",
+						},
+					},
+				},
+			},
+		},
+		"executionStats": bson.M{
+			"nReturned":           int32(25),
+			"totalDocsExamined":   int32(25),
+			"totalKeysExamined":   int32(26),
+			"executionTimeMillis": int32(3),
+		},
+	})
+	assert.Equal(t, "LIMIT", e.Stage)
+	assert.Equal(t, []string{"LIMIT", "FETCH", "IXSCAN"}, e.Stages)
+	assert.Equal(t, []string{"status_1_created_at_-1"}, e.Indexes)
+	assert.True(t, e.IndexUsed())
+	assert.False(t, e.CollectionScan)
+	assert.Equal(t, int64(25), e.Returned)
+	assert.Equal(t, int64(25), e.DocsExamined)
+	assert.Equal(t, int64(26), e.KeysExamined)
+	assert.Equal(t, 3*time.Millisecond, e.ExecutionTime)
+
+	e = parseExplanation(bson.M{
+		"queryPlanner": bson.M{
+			"winningPlan": bson.M{"stage": "COLLSCAN"},
+		},
+	})
+	assert.Equal(t, "COLLSCAN", e.Stage)
+	assert.True(t, e.CollectionScan)
+	assert.False(t, e.IndexUsed())
+
+	// aggregate
+	e = parseExplanation(bson.M{
+		"stages": bson.A{
+			bson.M{"$cursor": bson.M{
+				"queryPlanner":   bson.M{"winningPlan": bson.M{"stage": "IXSCAN", "indexName": "status_1"}},
+				"executionStats": bson.M{"nReturned": int32(7)},
+			}},
+			bson.M{"$group": bson.M{}},
+		},
+	})
+	assert.Equal(t, "IXSCAN", e.Stage)
+	assert.Equal(t, []string{"status_1"}, e.Indexes)
+	assert.Equal(t, int64(7), e.Returned)
+}
@@ -0,0 +1,207 @@
+package grimoire
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/bsontype"
+)
+
+// ErrUnknownFormat is returned when exporting or importing with an unknown format.
+var ErrUnknownFormat = errors.New("unknown format")
+
+// Format is the format of exported and imported documents.
+type Format string
+
+const (
+	// FormatJSONLines writes a document per line, in relaxed Extended JSON, which is readable
+	// but loses some BSON types, e.g. int32 and int64 are both written as numbers.
+	FormatJSONLines Format = "jsonl"
+	// FormatExtendedJSON writes a document per line, in canonical Extended JSON, which keeps the
+	// BSON types.
+	FormatExtendedJSON Format = "extjson"
+	// FormatCSV writes a header with the columns, then a line per document. Object ids are
+	// written in hex, dates in RFC 3339, and arrays and documents in relaxed Extended JSON.
+	FormatCSV Format = "csv"
+)
+
+// ParseFormat returns the format named s.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatJSONLines, FormatExtendedJSON, FormatCSV:
+		return f, nil
+	}
+	return "", fmt.Errorf("grimoire: %q: %w", s, ErrUnknownFormat)
+}
+
+// ExportOptions configures an export, see QueryBuilder.Export.
+type ExportOptions struct {
+	// Format defaults to FormatJSONLines.
+	Format Format
+	// Columns are the dotted BSON paths of the CSV columns, e.g. "timestamps.found". They default
+	// to the fields of the first document.
+	Columns []string
+}
+
+// Export writes the documents of the query to w, as they are stored in the database, and returns
+// how many were written. The limit, skip, sort and projection of the query are applied, and the
+// documents are read with a cursor, so queries with no limit can export whole collections.
+//
+// Example:
+//
+//	n, err := s.Query().Where("status", "done").Limit(0).Export(w, ExportOptions{Format: FormatCSV, Columns: []string{"_id", "status"}})
+func (q *QueryBuilder[T]) Export(w io.Writer, opts ExportOptions) (int64, error) {
+	e, err := newExporter(w, opts)
+	if err != nil {
+		return 0, err
+	}
+
+	var n int64
+	err = q.store.observe(q.context(), q.operation("export", q.filter()), func(ctx context.Context) (int64, error) {
+		if err := q.before("find"); err != nil {
+			return 0, err
+		}
+		coll, err := q.collection()
+		if err != nil {
+			return 0, err
+		}
+		cursor, err := coll.Find(ctx, q.filter(), q.options())
+		if err != nil {
+			return 0, err
+		}
+		defer cursor.Close(ctx)
+
+		for cursor.Next(ctx) {
+			if err := e.write(cursor.Current); err != nil {
+				return n, err
+			}
+			n++
+		}
+		if err := cursor.Err(); err != nil {
+			return n, err
+		}
+		return n, e.flush()
+	})
+	return n, err
+}
+
+// Export writes all documents of the store matching the default scopes to w, see
+// QueryBuilder.Export.
+func (s *Store[T]) Export(w io.Writer, opts ExportOptions) (int64, error) {
+	return s.Query().Limit(0).Export(w, opts)
+}
+
+// exporter writes documents in a format.
+type exporter struct {
+	opts ExportOptions
+	w    *bufio.Writer
+	csv  *csv.Writer
+}
+
+func newExporter(w io.Writer, opts ExportOptions) (*exporter, error) {
+	if opts.Format == "" {
+		opts.Format = FormatJSONLines
+	}
+	if _, err := ParseFormat(string(opts.Format)); err != nil {
+		return nil, err
+	}
+
+	e := &exporter{opts: opts, w: bufio.NewWriter(w)}
+	if opts.Format == FormatCSV {
+		e.csv = csv.NewWriter(e.w)
+		if opts.Columns != nil {
+			if err := e.csv.Write(opts.Columns); err != nil {
+				return nil, err
+			}
+		}
+	}
+	return e, nil
+}
+
+func (e *exporter) write(doc bson.Raw) error {
+	if e.csv == nil {
+		data, err := bson.MarshalExtJSON(doc, e.opts.Format == FormatExtendedJSON, false)
+		if err != nil {
+			return err
+		}
+		if _, err := e.w.Write(data); err != nil {
+			return err
+		}
+		return e.w.WriteByte('\n')
+	}
+
+	if e.opts.Columns == nil {
+		elems, err := doc.Elements()
+		if err != nil {
+			return err
+		}
+		for _, el := range elems {
+			e.opts.Columns = append(e.opts.Columns, el.Key())
+		}
+		if err := e.csv.Write(e.opts.Columns); err != nil {
+			return err
+		}
+	}
+
+	row := make([]string, len(e.opts.Columns))
+	for i, col := range e.opts.Columns {
+		v, err := doc.LookupErr(strings.Split(col, ".")...)
+		if err != nil {
+			continue // missing fields are empty
+		}
+		if row[i], err = csvValue(v); err != nil {
+			return fmt.Errorf("grimoire: column %s: %w", col, err)
+		}
+	}
+	return e.csv.Write(row)
+}
+
+func (e *exporter) flush() error {
+	if e.csv != nil {
+		e.csv.Flush()
+		if err := e.csv.Error(); err != nil {
+			return err
+		}
+	}
+	return e.w.Flush()
+}
+
+// csvValue formats a BSON value for a CSV cell.
+func csvValue(v bson.RawValue) (string, error) {
+	switch v.Type {
+	case bsontype.String:
+		return v.StringValue(), nil
+	case bsontype.Symbol:
+		return v.Symbol(), nil
+	case bsontype.ObjectID:
+		return v.ObjectID().Hex(), nil
+	case bsontype.DateTime:
+		return v.Time().UTC().Format(time.RFC3339Nano), nil
+	case bsontype.Boolean:
+		return strconv.FormatBool(v.Boolean()), nil
+	case bsontype.Int32:
+		return strconv.FormatInt(int64(v.Int32()), 10), nil
+	case bsontype.Int64:
+		return strconv.FormatInt(v.Int64(), 10), nil
+	case bsontype.Double:
+		return strconv.FormatFloat(v.Double(), 'g', -1, 64), nil
+	case bsontype.Null, bsontype.Undefined:
+		return "", nil
+	}
+
+	// other values are written as the value of a relaxed Extended JSON document
+	data, err := bson.MarshalExtJSON(bson.D{{Key: "v", Value: v}}, false, false)
+	if err != nil {
+		return "", err
+	}
+	s := strings.TrimPrefix(string(data), `{"v":`)
+	return strings.TrimSuffix(s, "}"), nil
+}
@@ -0,0 +1,248 @@
+package grimoire
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestExportImport_Export(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("6650e3a4f1d2c3b4a5968778")
+	found := time.Date(2024, 5, 24, 18, 30, 0, 0, time.UTC)
+	docs := []bson.D{
+		{
+			{Key: "_id", Value: id},
+			{Key: "status", Value: "done"},
+			{Key: "size", Value: int64(1024)},
+			{Key: "timestamps", Value: bson.D{{Key: "found", Value: found}}},
+			{Key: "tags", Value: bson.A{"a", "b"}},
+		},
+		{
+			{Key: "_id", Value: id},
+			{Key: "status", Value: "queued, \"again\""},
+			{Key: "size", Value: int32(2)},
+		},
+	}
+	export := func(opts ExportOptions) string {
+		buf := &bytes.Buffer{}
+		e, err := newExporter(buf, opts)
+		assert.NoError(t, err)
+		for _, d := range docs {
+			raw, err := bson.Marshal(d)
+			assert.NoError(t, err)
+			assert.NoError(t, e.write(raw))
+		}
+		assert.NoError(t, e.flush())
+		return buf.String()
+	}
+
+	assert.Equal(t, `{"_id":{"$oid":"6650e3a4f1d2c3b4a5968778"},"status":"done","size":1024,"timestamps":{"found":{"$date":"2024-05-24T18:30:00Z"}},"tags":["a","b"]}
+{"_id":{"$oid":"6650e3a4f1d2c3b4a5968778"},"status":"queued, \"again\"","size":2}
+`, export(ExportOptions{}))
+
+	lines := strings.Split(export(ExportOptions{Format: FormatExtendedJSON}), "\n")
+	assert.Contains(t, lines[0], `"size":{"$numberLong":"1024"}`)
+	assert.Contains(t, lines[1], `"size":{"$numberInt":"2"}`)
+
+	assert.Equal(t, `_id,status,size,timestamps,tags
+6650e3a4f1d2c3b4a5968778,done,1024,"{""found"":{""$date"":""2024-05-24T18:30:00Z""}}","[""a"",""b""]"
+6650e3a4f1d2c3b4a5968778,"queued, ""again""",2,,
+`, export(ExportOptions{Format: FormatCSV}))
+
+	assert.Equal(t, `status,timestamps.found,missing
+done,2024-05-24T18:30:00Z,
+"queued, ""again""",,
+`, export(ExportOptions{Format: FormatCSV, Columns: []string{"status", "timestamps.found", "missing"}}))
+
+	_, err := newExporter(&bytes.Buffer{}, ExportOptions{Format: "xml"})
+	assert.True(t, errors.Is(err, ErrUnknownFormat))
+}
+
+func TestExportImport_Import(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	read := func(opts ImportOptions, input string) *importer[*Download] {
+		opts.BatchSize = 100 // not flushed
+		im := &importer[*Download]{store: s, ctx: context.Background(), opts: opts, res: &ImportResult{}}
+		if opts.Format == FormatCSV {
+			assert.NoError(t, im.readCSV(strings.NewReader(input)))
+		} else {
+			assert.NoError(t, im.readJSON(strings.NewReader(input)))
+		}
+		return im
+	}
+	document := func(m mongo.WriteModel) *Download {
+		switch m := m.(type) {
+		case *mongo.InsertOneModel:
+			return m.Document.(*Download)
+		case *mongo.ReplaceOneModel:
+			return m.Replacement.(*Download)
+		}
+		return nil
+	}
+
+	im := read(ImportOptions{Upsert: true}, `{"_id":{"$oid":"6650e3a4f1d2c3b4a5968778"},"status":"done","download_files":[{"num":2}]}
+
+{"status":"queued","timestamps":{"found":{"$date":"2024-05-24T18:30:00Z"}}}
+{"status":
+{"status":"loading","multi":"yes"}
+`)
+	assert.Equal(t, 4, im.res.Read)
+	assert.Equal(t, []int{1, 3}, im.lines)
+	if assert.Len(t, im.models, 2) {
+		replace, ok := im.models[0].(*mongo.ReplaceOneModel)
+		if assert.True(t, ok, "upsert") {
+			assert.Equal(t, "6650e3a4f1d2c3b4a5968778", replace.Filter.(bson.M)["_id"].(primitive.ObjectID).Hex())
+			assert.Equal(t, 2, document(replace).Files[0].Num)
+		}
+		insert, ok := im.models[1].(*mongo.InsertOneModel)
+		if assert.True(t, ok, "no id") {
+			assert.Equal(t, time.Date(2024, 5, 24, 18, 30, 0, 0, time.UTC), document(insert).Timestamps.Found.UTC())
+		}
+	}
+	if assert.Len(t, im.res.Errors, 2) {
+		assert.Equal(t, 4, im.res.Errors[0].Line)
+		assert.Equal(t, 5, im.res.Errors[1].Line)
+	}
+
+	im = read(ImportOptions{Format: FormatCSV}, `_id,status,multi,timestamps.found,download_files,unknown
+6650e3a4f1d2c3b4a5968778,done,true,2024-05-24T18:30:00Z,"[{""num"":3}]",x
+,"queued, again",false,,,
+,bad,maybe,,,
+`)
+	assert.Equal(t, 3, im.res.Read)
+	assert.Equal(t, []int{2, 3}, im.lines)
+	if assert.Len(t, im.models, 2) {
+		_, ok := im.models[0].(*mongo.InsertOneModel)
+		assert.True(t, ok, "no upsert")
+		d := document(im.models[0])
+		assert.Equal(t, "6650e3a4f1d2c3b4a5968778", d.ID.Hex())
+		assert.Equal(t, "done", d.Status)
+		assert.True(t, d.Multi)
+		assert.Equal(t, time.Date(2024, 5, 24, 18, 30, 0, 0, time.UTC), d.Timestamps.Found.UTC())
+		assert.Equal(t, 3, d.Files[0].Num)
+		assert.Equal(t, "queued, again", document(im.models[1]).Status)
+	}
+	if assert.Len(t, im.res.Errors, 1) {
+		assert.Equal(t, 4, im.res.Errors[0].Line)
+		assert.Contains(t, im.res.Errors[0].Error(), "line 4: column multi")
+	}
+}
+
+func TestExportImport_CSVCell(t *testing.T) {
+	fields := modelFields[*Download]()
+
+	v, err := csvCell("status", "[HorribleSubs] Show - 01", fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "[HorribleSubs] Show - 01", v)
+
+	v, err = csvCell("url", "{not json}", fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "{not json}", v)
+
+	v, err = csvCell("download_files", `[{"num":3}]`, fields)
+	assert.NoError(t, err)
+	assert.IsType(t, bson.A{}, v)
+
+	v, err = csvCell("unknown", `["a"]`, fields)
+	assert.NoError(t, err)
+	assert.Equal(t, bson.A{"a"}, v)
+
+	v, err = csvCell("unknown", "[HorribleSubs] Show - 01", fields)
+	assert.NoError(t, err)
+	assert.Equal(t, "[HorribleSubs] Show - 01", v, "not Extended JSON")
+
+	id := primitive.NewObjectID()
+	found := time.Date(2024, 5, 24, 18, 30, 0, 0, time.UTC)
+	for cell, value := range map[string]interface{}{
+		"done":                 "done",
+		"true":                 true,
+		"2":                    int32(2),
+		"-1":                   int32(-1),
+		"8589934592":           int64(8589934592),
+		"1.5":                  1.5,
+		"1e+21":                1e21,
+		"Infinity":             "Infinity",
+		id.Hex():               id,
+		"2024-05-24T18:30:00Z": found,
+		`{"found":{"$date":"2024-05-24T18:30:00Z"}}`: bson.D{{Key: "found", Value: primitive.NewDateTimeFromTime(found)}},
+	} {
+		v, err := csvCell("unknown", cell, fields)
+		assert.NoError(t, err)
+		assert.Equal(t, value, v, cell)
+	}
+}
+
+// row is a model without fields, whose CSV cells are all inferred.
+type row bson.M
+
+func (r row) PrepareID(id interface{}) (interface{}, error) {
+	return id, nil
+}
+
+func (r row) GetID() interface{} {
+	return r["_id"]
+}
+
+func (r row) SetID(id interface{}) {
+	r["_id"] = id
+}
+
+func TestExportImport_CSVRoundTrip(t *testing.T) {
+	s, err := New[row]("mongodb://localhost:27017", "grimoire", "rows")
+	assert.NoError(t, err)
+
+	doc := bson.D{
+		{Key: "_id", Value: primitive.NewObjectID()},
+		{Key: "status", Value: "[group] title"},
+		{Key: "count", Value: int32(3)},
+		{Key: "size", Value: int64(8589934592)},
+		{Key: "ratio", Value: 0.25},
+		{Key: "done", Value: false},
+		{Key: "found", Value: primitive.NewDateTimeFromTime(time.Date(2024, 5, 24, 18, 30, 0, 0, time.UTC))},
+		{Key: "medium_id", Value: primitive.NewObjectID()},
+		{Key: "tags", Value: bson.A{"a", "b"}},
+		{Key: "timestamps", Value: bson.D{{Key: "loaded", Value: primitive.NewDateTimeFromTime(time.Date(2024, 5, 25, 0, 0, 0, 0, time.UTC))}}},
+	}
+	raw, err := bson.Marshal(doc)
+	assert.NoError(t, err)
+
+	buf := &bytes.Buffer{}
+	e, err := newExporter(buf, ExportOptions{Format: FormatCSV})
+	assert.NoError(t, err)
+	assert.NoError(t, e.write(raw))
+	assert.NoError(t, e.flush())
+
+	im := &importer[row]{store: s, ctx: context.Background(), opts: ImportOptions{BatchSize: 100}, res: &ImportResult{}}
+	assert.NoError(t, im.readCSV(buf))
+	assert.Empty(t, im.res.Errors)
+	if assert.Len(t, im.models, 1) {
+		expected := row{}
+		assert.NoError(t, bson.Unmarshal(raw, &expected))
+		imported, err := bson.Marshal(im.models[0].(*mongo.InsertOneModel).Document)
+		assert.NoError(t, err)
+		actual := row{}
+		assert.NoError(t, bson.Unmarshal(imported, &actual))
+		assert.Equal(t, expected, actual)
+	}
+}
+
+func TestExportImport_SetPath(t *testing.T) {
+	doc := bson.D{}
+	doc = setPath(doc, []string{"a"}, 1)
+	doc = setPath(doc, []string{"b", "c"}, 2)
+	doc = setPath(doc, []string{"b", "d"}, 3)
+	doc = setPath(doc, []string{"a"}, 4)
+	assert.Equal(t, bson.D{
+		{Key: "a", Value: 4},
+		{Key: "b", Value: bson.D{{Key: "c", Value: 2}, {Key: "d", Value: 3}}},
+	}, doc)
+}
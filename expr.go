@@ -0,0 +1,151 @@
+package grimoire
+
+import (
+	"github.com/kamva/mgm/v3/operator"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// FieldRef returns a reference to the value of field for use in expressions.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	FieldRef("total_count") // "$total_count"
+func FieldRef(field string) string {
+	return "$" + field
+}
+
+// Literal returns value without parsing it as an expression, for example for strings
+// that start with '$'.
+func Literal(value interface{}) bson.M {
+	return bson.M{"$literal": value}
+}
+
+// Add returns an expression that adds numbers together, or adds milliseconds to a date.
+//
+// Example:
+//
+//	Add(FieldRef("completed_count"), FieldRef("failed_count"))
+func Add(args ...interface{}) bson.M {
+	return bson.M{"$add": bson.A(args)}
+}
+
+// Subtract returns an expression that subtracts b from a.
+func Subtract(a, b interface{}) bson.M {
+	return bson.M{"$subtract": bson.A{a, b}}
+}
+
+// Multiply returns an expression that multiplies numbers together.
+func Multiply(args ...interface{}) bson.M {
+	return bson.M{"$multiply": bson.A(args)}
+}
+
+// Divide returns an expression that divides a by b.
+func Divide(a, b interface{}) bson.M {
+	return bson.M{"$divide": bson.A{a, b}}
+}
+
+// DateAdd returns an expression that adds amount units to date.
+// NOTE: unit should be one of year, quarter, week, month, day, hour, minute, second or millisecond.
+//
+// Example:
+//
+//	DateAdd(FieldRef("processed_at"), "hour", 1)
+func DateAdd(date interface{}, unit string, amount interface{}) bson.M {
+	return bson.M{"$dateAdd": bson.M{"startDate": date, "unit": unit, "amount": amount}}
+}
+
+// DateSubtract returns an expression that subtracts amount units from date.
+// NOTE: unit should be one of year, quarter, week, month, day, hour, minute, second or millisecond.
+func DateSubtract(date interface{}, unit string, amount interface{}) bson.M {
+	return bson.M{"$dateSubtract": bson.M{"startDate": date, "unit": unit, "amount": amount}}
+}
+
+// DateDiff returns an expression for the number of whole units between start and end.
+// NOTE: unit should be one of year, quarter, week, month, day, hour, minute, second or millisecond.
+func DateDiff(start, end interface{}, unit string) bson.M {
+	return bson.M{"$dateDiff": bson.M{"startDate": start, "endDate": end, "unit": unit}}
+}
+
+// Expr adds an aggregation expression clause to the query.
+//
+// Example:
+//
+//	Expr(bson.M{"$lt": bson.A{"$completed_count", "$total_count"}})
+func (q *QueryBuilder[T]) Expr(expr interface{}) *QueryBuilder[T] {
+	return q.add(bson.M{operator.Expr: expr})
+}
+
+// Compare adds an expression clause to the query comparing left and right with op, which
+// should be one of the comparison operators ($eq, $ne, $lt, $lte, $gt or $gte). Either side
+// can be a value, a field reference or an expression.
+// NOTE: strings starting with '$' are field references, like those returned by FieldRef, so
+// string values that may start with '$' should be wrapped in Literal.
+//
+// Examples:
+//
+//	Compare(Subtract(FieldRef("total_count"), FieldRef("completed_count")), operator.Gt, 10)
+//	Compare(FieldRef("label"), operator.Eq, Literal("$5 off"))
+func (q *QueryBuilder[T]) Compare(left interface{}, op string, right interface{}) *QueryBuilder[T] {
+	return q.Expr(bson.M{op: bson.A{left, right}})
+}
+
+// FieldEqual adds a clause to the query matching when field is equal to other.
+// NOTE: field and other should be valid BSON fields.
+//
+// Example:
+//
+//	FieldEqual("completed_count", "total_count")
+func (q *QueryBuilder[T]) FieldEqual(field, other string) *QueryBuilder[T] {
+	return q.Compare(FieldRef(field), operator.Eq, FieldRef(other))
+}
+
+// FieldNotEqual adds a clause to the query matching when field is not equal to other.
+// NOTE: field and other should be valid BSON fields.
+//
+// Example:
+//
+//	FieldNotEqual("completed_count", "total_count")
+func (q *QueryBuilder[T]) FieldNotEqual(field, other string) *QueryBuilder[T] {
+	return q.Compare(FieldRef(field), operator.Ne, FieldRef(other))
+}
+
+// FieldLessThan adds a clause to the query matching when field is less than other.
+// NOTE: field and other should be valid BSON fields.
+//
+// Example:
+//
+//	FieldLessThan("completed_count", "total_count")
+func (q *QueryBuilder[T]) FieldLessThan(field, other string) *QueryBuilder[T] {
+	return q.Compare(FieldRef(field), operator.Lt, FieldRef(other))
+}
+
+// FieldLessThanEqual adds a clause to the query matching when field is less than or equal to other.
+// NOTE: field and other should be valid BSON fields.
+//
+// Example:
+//
+//	FieldLessThanEqual("completed_count", "total_count")
+func (q *QueryBuilder[T]) FieldLessThanEqual(field, other string) *QueryBuilder[T] {
+	return q.Compare(FieldRef(field), operator.Lte, FieldRef(other))
+}
+
+// FieldGreaterThan adds a clause to the query matching when field is greater than other.
+// NOTE: field and other should be valid BSON fields.
+//
+// Example:
+//
+//	FieldGreaterThan("updated_at", "processed_at")
+func (q *QueryBuilder[T]) FieldGreaterThan(field, other string) *QueryBuilder[T] {
+	return q.Compare(FieldRef(field), operator.Gt, FieldRef(other))
+}
+
+// FieldGreaterThanEqual adds a clause to the query matching when field is greater than or equal to other.
+// NOTE: field and other should be valid BSON fields.
+//
+// Example:
+//
+//	FieldGreaterThanEqual("updated_at", "processed_at")
+func (q *QueryBuilder[T]) FieldGreaterThanEqual(field, other string) *QueryBuilder[T] {
+	return q.Compare(FieldRef(field), operator.Gte, FieldRef(other))
+}
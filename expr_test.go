@@ -0,0 +1,44 @@
+package grimoire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/kamva/mgm/v3/operator"
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestQueryBuilder_Expr(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	q := s.Query().
+		Where("status", "done").
+		FieldLessThan("completed_count", "total_count").
+		FieldGreaterThan("updated_at", "created_at").
+		Compare(DateAdd(FieldRef("created_at"), "hour", 1), operator.Lt, since).
+		Compare(Subtract(FieldRef("total_count"), FieldRef("completed_count")), operator.Gte, 10)
+
+	assert.Equal(t, []bson.M{
+		{"status": bson.M{"$eq": "done"}},
+		{"$expr": bson.M{"$lt": bson.A{"$completed_count", "$total_count"}}},
+		{"$expr": bson.M{"$gt": bson.A{"$updated_at", "$created_at"}}},
+		{"$expr": bson.M{"$lt": bson.A{
+			bson.M{"$dateAdd": bson.M{"startDate": "$created_at", "unit": "hour", "amount": 1}},
+			since,
+		}}},
+		{"$expr": bson.M{"$gte": bson.A{
+			bson.M{"$subtract": bson.A{"$total_count", "$completed_count"}},
+			10,
+		}}},
+	}, q.values)
+
+	// string values starting with $ are literals, not field references
+	q = s.Query().Compare(FieldRef("label"), operator.Eq, Literal("$5 off"))
+	assert.Equal(t, []bson.M{
+		{"$expr": bson.M{"$eq": bson.A{"$label", bson.M{"$literal": "$5 off"}}}},
+	}, q.values)
+}
@@ -0,0 +1,195 @@
+package grimoire
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	objectIDType = reflect.TypeOf(primitive.ObjectID{})
+	symbolType   = reflect.TypeOf(primitive.Symbol(""))
+)
+
+// modelField describes a field of a model as it is stored in the database.
+type modelField struct {
+	// Path is the dotted BSON path of the field, e.g. "timestamps.found".
+	Path string
+	// Type is the Go type of the field, with pointers removed.
+	Type reflect.Type
+	// Struct is the Go struct field.
+	Struct reflect.StructField
+}
+
+// modelType returns the struct type of the model T.
+func modelType[T mgm.Model]() reflect.Type {
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// modelFields returns the fields of the model T keyed by BSON path, including the fields
+// of nested structs and of structs in slices.
+func modelFields[T mgm.Model]() map[string]modelField {
+	fields := map[string]modelField{}
+	collectFields(fields, modelType[T](), "", 0)
+	return fields
+}
+
+func collectFields(fields map[string]modelField, t reflect.Type, prefix string, depth int) {
+	if depth > 8 {
+		return
+	}
+
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+
+		name, inline, skip := bsonName(sf)
+		if skip {
+			continue
+		}
+
+		ft := indirect(sf.Type)
+		if inline {
+			if ft.Kind() == reflect.Struct {
+				collectFields(fields, ft, prefix, depth+1)
+			}
+			continue
+		}
+
+		path := prefix + name
+		fields[path] = modelField{Path: path, Type: ft, Struct: sf}
+
+		if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			ft = indirect(ft.Elem())
+		}
+		if ft.Kind() == reflect.Struct && ft != timeType {
+			collectFields(fields, ft, path+".", depth+1)
+		}
+	}
+}
+
+// bsonName returns the BSON key of a struct field, following the rules of the driver.
+func bsonName(sf reflect.StructField) (name string, inline bool, skip bool) {
+	name = strings.ToLower(sf.Name)
+	tag, ok := sf.Tag.Lookup("bson")
+	if !ok {
+		return name, false, false
+	}
+	if tag == "-" {
+		return "", false, true
+	}
+
+	vals := strings.Split(tag, ",")
+	if vals[0] != "" {
+		name = vals[0]
+	}
+	for _, v := range vals[1:] {
+		if v == "inline" {
+			inline = true
+		}
+	}
+	return name, inline, false
+}
+
+// fieldByBSONName returns the field of the struct v with the BSON key name, looking into inline
+// structs.
+func fieldByBSONName(v reflect.Value, name string) (reflect.Value, bool) {
+	index, ok := fieldIndexByBSONName(v.Type(), name)
+	if !ok {
+		return reflect.Value{}, false
+	}
+	return v.FieldByIndex(index), true
+}
+
+// fieldIndexByBSONName returns the index of the field of the struct type t with the BSON key
+// name, looking into inline structs.
+func fieldIndexByBSONName(t reflect.Type, name string) ([]int, bool) {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		n, inline, skip := bsonName(sf)
+		if skip {
+			continue
+		}
+		if inline {
+			if sf.Type.Kind() == reflect.Struct {
+				if index, ok := fieldIndexByBSONName(sf.Type, name); ok {
+					return append([]int{i}, index...), true
+				}
+			}
+			continue
+		}
+		if n == name {
+			return []int{i}, true
+		}
+	}
+	return nil, false
+}
+
+func indirect(t reflect.Type) reflect.Type {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t
+}
+
+// coerce converts the string s to a value suitable for querying a field of type t.
+func coerce(t reflect.Type, s string) (interface{}, error) {
+	t = indirect(t)
+	switch t {
+	case timeType:
+		for _, layout := range []string{time.RFC3339Nano, "2006-01-02T15:04:05", "2006-01-02"} {
+			if v, err := time.Parse(layout, s); err == nil {
+				return v, nil
+			}
+		}
+		return nil, fmt.Errorf("cannot parse %q as a time", s)
+	case objectIDType:
+		return primitive.ObjectIDFromHex(s)
+	case symbolType:
+		return s, nil // strings and symbols compare as equal
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return s, nil
+	case reflect.Bool:
+		return strconv.ParseBool(s)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.ParseInt(s, 10, t.Bits())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		v, err := strconv.ParseUint(s, 10, t.Bits())
+		if err != nil {
+			return nil, err
+		}
+		if v > math.MaxInt64 {
+			// BSON has no unsigned integers
+			return nil, fmt.Errorf("%s: %w", s, ErrInvalidValue)
+		}
+		return int64(v), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.ParseFloat(s, t.Bits())
+	case reflect.Slice, reflect.Array:
+		// a scalar matches any element of an array field
+		return coerce(t.Elem(), s)
+	case reflect.Interface:
+		return s, nil
+	}
+
+	return nil, fmt.Errorf("unsupported type %s", t)
+}
@@ -0,0 +1,69 @@
+package grimoiretest
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dashotv/grimoire"
+)
+
+// AssertCount checks that the query matches n documents, and returns whether it does.
+//
+// Example:
+//
+//	grimoiretest.AssertCount(t, s.Query().Where("status", "done"), 2)
+func AssertCount[T mgm.Model](t testing.TB, q *grimoire.QueryBuilder[T], n int64) bool {
+	t.Helper()
+
+	count, err := q.Count()
+	if err != nil {
+		t.Errorf("grimoiretest: count %s: %s", q, err)
+		return false
+	}
+	if count != n {
+		t.Errorf("grimoiretest: %s matches %d documents, expected %d", q, count, n)
+		return false
+	}
+	return true
+}
+
+// AssertExists checks that the collection of s has a document with the id, and returns whether
+// it does. The default scopes of s are not applied, so that soft deleted documents exist.
+func AssertExists[T mgm.Model](t testing.TB, s *grimoire.Store[T], id primitive.ObjectID) bool {
+	t.Helper()
+
+	ok, err := exists(s, id)
+	if err != nil {
+		t.Errorf("grimoiretest: find %s in %s: %s", id.Hex(), s.Collection.Name(), err)
+		return false
+	}
+	if !ok {
+		t.Errorf("grimoiretest: %s has no document %s", s.Collection.Name(), id.Hex())
+	}
+	return ok
+}
+
+// AssertNotExists checks that the collection of s has no document with the id, and returns
+// whether it has none.
+func AssertNotExists[T mgm.Model](t testing.TB, s *grimoire.Store[T], id primitive.ObjectID) bool {
+	t.Helper()
+
+	ok, err := exists(s, id)
+	if err != nil {
+		t.Errorf("grimoiretest: find %s in %s: %s", id.Hex(), s.Collection.Name(), err)
+		return false
+	}
+	if ok {
+		t.Errorf("grimoiretest: %s has a document %s", s.Collection.Name(), id.Hex())
+	}
+	return !ok
+}
+
+func exists[T mgm.Model](s *grimoire.Store[T], id primitive.ObjectID) (bool, error) {
+	n, err := s.Collection.CountDocuments(context.Background(), bson.M{"_id": id})
+	return n > 0, err
+}
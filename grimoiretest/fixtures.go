@@ -0,0 +1,161 @@
+package grimoiretest
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"gopkg.in/yaml.v3"
+
+	"github.com/dashotv/grimoire"
+)
+
+// LoadFixtures reads the documents of the fixtures file path, see ReadFixtures, and inserts them
+// in the collection of s, as they are: the model hooks are not called. Documents with no _id are
+// given a new one. The documents are returned in the order of the file.
+//
+// Example:
+//
+//	downloads := grimoiretest.LoadFixtures(t, s, "testdata/downloads.yaml")
+func LoadFixtures[T mgm.Model](t testing.TB, s *grimoire.Store[T], path string) []T {
+	t.Helper()
+
+	list, err := ReadFixtures[T](path)
+	if err != nil {
+		t.Fatalf("grimoiretest: %s", err)
+	}
+	if len(list) == 0 {
+		return list
+	}
+
+	docs := make([]interface{}, len(list))
+	for i, o := range list {
+		if id, ok := o.GetID().(primitive.ObjectID); !ok || id.IsZero() {
+			o.SetID(primitive.NewObjectID())
+		}
+		docs[i] = o
+	}
+	if _, err := s.Collection.InsertMany(context.Background(), docs); err != nil {
+		t.Fatalf("grimoiretest: load %s: %s", path, err)
+	}
+	return list
+}
+
+// ReadFixtures reads the documents of the fixtures file path, by its extension:
+//   - .yaml and .yml files are a list of documents
+//   - .json files are an array of documents, or a single document
+//   - .jsonl files are a document per line, like the exports of Store.Export
+//
+// Documents are decoded from Extended JSON, so that the BSON types of YAML documents are written
+// the same way, e.g. {$oid: 6650e3a4f1d2c3b4a5968778} and {$date: "2024-05-24T18:30:00Z"}.
+func ReadFixtures[T mgm.Model](path string) ([]T, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("fixtures: %w", err)
+	}
+
+	var docs [][]byte
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		docs, err = yamlDocuments(data)
+	case ".json":
+		docs, err = jsonDocuments(data)
+	case ".jsonl":
+		docs, err = lineDocuments(data)
+	default:
+		err = fmt.Errorf("unknown extension %q", ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("fixtures %s: %w", path, err)
+	}
+
+	list := make([]T, 0, len(docs))
+	for i, doc := range docs {
+		o, err := decode[T](doc)
+		if err != nil {
+			return nil, fmt.Errorf("fixtures %s: document %d: %w", path, i+1, err)
+		}
+		list = append(list, o)
+	}
+	return list, nil
+}
+
+func yamlDocuments(data []byte) ([][]byte, error) {
+	list := []interface{}{}
+	if err := yaml.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+
+	docs := make([][]byte, len(list))
+	for i, v := range list {
+		if _, ok := v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("document %d is not a mapping", i+1)
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			return nil, fmt.Errorf("document %d: %w", i+1, err)
+		}
+		docs[i] = data
+	}
+	return docs, nil
+}
+
+func jsonDocuments(data []byte) ([][]byte, error) {
+	data = bytes.TrimSpace(data)
+	if !bytes.HasPrefix(data, []byte("[")) {
+		return [][]byte{data}, nil
+	}
+
+	list := []json.RawMessage{}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, err
+	}
+	docs := make([][]byte, len(list))
+	for i, raw := range list {
+		docs[i] = raw
+	}
+	return docs, nil
+}
+
+func lineDocuments(data []byte) ([][]byte, error) {
+	docs := [][]byte{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		docs = append(docs, append([]byte(nil), line...))
+	}
+	return docs, scanner.Err()
+}
+
+// decode returns a new T decoded from the Extended JSON document data.
+func decode[T mgm.Model](data []byte) (T, error) {
+	var zero T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() != reflect.Ptr {
+		v := reflect.New(t)
+		if err := bson.UnmarshalExtJSON(data, false, v.Interface()); err != nil {
+			return zero, err
+		}
+		return v.Elem().Interface().(T), nil
+	}
+
+	v := reflect.New(t.Elem())
+	if err := bson.UnmarshalExtJSON(data, false, v.Interface()); err != nil {
+		return zero, err
+	}
+	return v.Interface().(T), nil
+}
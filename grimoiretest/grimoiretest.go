@@ -0,0 +1,117 @@
+// Package grimoiretest helps testing code using grimoire stores: each test gets its own
+// database, which is dropped when the test ends, fixtures are loaded from YAML or JSON files,
+// and assertions check the documents of the stores.
+//
+// Example:
+//
+//	func TestDownloads(t *testing.T) {
+//		db := grimoiretest.New(t)
+//		s := grimoiretest.Store[*Download](db, "downloads")
+//		list := grimoiretest.LoadFixtures(t, s, "testdata/downloads.yaml")
+//
+//		grimoiretest.AssertCount(t, s.Query().Where("status", "done"), 2)
+//		grimoiretest.AssertExists(t, s, list[0].ID)
+//	}
+package grimoiretest
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+
+	"github.com/dashotv/grimoire"
+)
+
+// DefaultURI is the server of the test databases, unless the GRIMOIRE_TEST_URI environment
+// variable is set.
+const DefaultURI = "mongodb://localhost:27017"
+
+// DB is a database for a single test.
+type DB struct {
+	t        testing.TB
+	URI      string
+	Name     string
+	Client   *mongo.Client
+	Database *mongo.Database
+}
+
+// New creates a uniquely named database for the test t, which is dropped when the test ends.
+// The test is skipped when the server cannot be reached.
+func New(t testing.TB) *DB {
+	t.Helper()
+
+	uri := strings.TrimSpace(os.Getenv("GRIMOIRE_TEST_URI"))
+	if uri == "" {
+		uri = DefaultURI
+	}
+	client, err := mgm.NewClient(grimoire.CustomClientOptions(uri))
+	if err != nil {
+		t.Fatalf("grimoiretest: connect to %s: %s", uri, err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx, readpref.Primary()); err != nil {
+		_ = client.Disconnect(context.Background())
+		t.Skipf("grimoiretest: no server at %s: %s", uri, err)
+	}
+
+	db := &DB{t: t, URI: uri, Name: databaseName(t.Name()), Client: client}
+	db.Database = client.Database(db.Name)
+	t.Cleanup(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+		defer cancel()
+		if err := db.Database.Drop(ctx); err != nil {
+			t.Errorf("grimoiretest: drop %s: %s", db.Name, err)
+		}
+		_ = client.Disconnect(ctx)
+	})
+	return db
+}
+
+// Store creates a store of the collection of the test database db. Its client is disconnected
+// when the test ends.
+func Store[T mgm.Model](db *DB, collection string) *grimoire.Store[T] {
+	db.t.Helper()
+
+	s, err := grimoire.New[T](db.URI, db.Name, collection)
+	if err != nil {
+		db.t.Fatalf("grimoiretest: store %s: %s", collection, err)
+	}
+	db.t.Cleanup(func() {
+		_ = s.Client.Disconnect(context.Background())
+	})
+	return s
+}
+
+// databaseName returns a unique database name for the test name. Database names are limited to
+// 63 bytes, and cannot contain most punctuation.
+func databaseName(test string) string {
+	b := strings.Builder{}
+	for _, r := range test {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	name := b.String()
+	if len(name) > 40 {
+		name = name[:40]
+	}
+
+	suffix := make([]byte, 6)
+	if _, err := rand.Read(suffix); err != nil {
+		panic(err)
+	}
+	return "test_" + name + "_" + hex.EncodeToString(suffix)
+}
@@ -0,0 +1,106 @@
+package grimoiretest
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/dashotv/grimoire"
+)
+
+type Task struct {
+	grimoire.Document `bson:",inline"`
+	Title             string    `bson:"title"`
+	Status            string    `bson:"status"`
+	Points            int       `bson:"points"`
+	Due               time.Time `bson:"due"`
+	Tags              []string  `bson:"tags"`
+}
+
+func TestDatabaseName(t *testing.T) {
+	valid := regexp.MustCompile(`^test_[A-Za-z0-9_]+_[0-9a-f]{12}$`)
+
+	name := databaseName("TestStore/sub test.with $chars")
+	assert.Regexp(t, valid, name)
+	assert.True(t, strings.HasPrefix(name, "test_TestStore_sub_test_with__chars_"), name)
+
+	name = databaseName(strings.Repeat("TestLong", 20))
+	assert.Regexp(t, valid, name)
+	assert.Less(t, len(name), 64)
+
+	assert.NotEqual(t, databaseName("TestSame"), databaseName("TestSame"))
+}
+
+func TestReadFixtures(t *testing.T) {
+	id, _ := primitive.ObjectIDFromHex("6650e3a4f1d2c3b4a5968778")
+	due := time.Date(2024, 5, 24, 18, 30, 0, 0, time.UTC)
+
+	for _, file := range []string{"tasks.yaml", "tasks.json", "tasks.jsonl"} {
+		t.Run(file, func(t *testing.T) {
+			list, err := ReadFixtures[*Task](filepath.Join("testdata", file))
+			if !assert.NoError(t, err) || !assert.Len(t, list, 2) {
+				return
+			}
+			assert.Equal(t, id, list[0].ID)
+			assert.Equal(t, "write tests", list[0].Title)
+			assert.Equal(t, 3, list[0].Points)
+			assert.Equal(t, due, list[0].Due.UTC())
+			assert.True(t, list[1].ID.IsZero())
+			assert.Equal(t, []string{"a", "b"}, list[1].Tags)
+		})
+	}
+}
+
+func TestReadFixtures_Errors(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, data string) string {
+		path := filepath.Join(dir, name)
+		assert.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+		return path
+	}
+
+	_, err := ReadFixtures[*Task](write("tasks.txt", ""))
+	assert.ErrorContains(t, err, `unknown extension ".txt"`)
+
+	_, err = ReadFixtures[*Task](write("scalars.yaml", "- a\n- b\n"))
+	assert.ErrorContains(t, err, "document 1 is not a mapping")
+
+	_, err = ReadFixtures[*Task](write("types.jsonl", `{"title":"ok"}`+"\n"+`{"points":"three"}`))
+	assert.ErrorContains(t, err, "document 2")
+
+	_, err = ReadFixtures[*Task](filepath.Join(dir, "missing.json"))
+	assert.ErrorIs(t, err, os.ErrNotExist)
+
+	list, err := ReadFixtures[*Task](write("single.json", `{"title":"only"}`))
+	if assert.NoError(t, err) && assert.Len(t, list, 1) {
+		assert.Equal(t, "only", list[0].Title)
+	}
+}
+
+func TestDB(t *testing.T) {
+	db := New(t)
+	assert.True(t, strings.HasPrefix(db.Name, "test_TestDB_"), db.Name)
+
+	s := Store[*Task](db, "tasks")
+	list := LoadFixtures(t, s, "testdata/tasks.yaml")
+	if !assert.Len(t, list, 2) {
+		return
+	}
+	assert.False(t, list[1].ID.IsZero(), "new id")
+
+	AssertCount(t, s.Query(), 2)
+	AssertCount(t, s.Query().Where("status", "done"), 1)
+	AssertExists(t, s, list[0].ID)
+	AssertExists(t, s, list[1].ID)
+	AssertNotExists(t, s, primitive.NewObjectID())
+
+	assert.NoError(t, s.Delete(list[0]))
+	AssertNotExists(t, s, list[0].ID)
+	AssertCount(t, s.Query(), 1)
+}
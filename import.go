@@ -0,0 +1,361 @@
+package grimoire
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// ImportOptions configures an import, see Store.Import.
+type ImportOptions struct {
+	// Format defaults to FormatJSONLines, which also reads canonical Extended JSON.
+	Format Format
+	// BatchSize is the number of documents written by each bulk write, 1000 by default.
+	BatchSize int
+	// Upsert replaces the documents with the same _id, instead of failing to insert them.
+	Upsert bool
+}
+
+// LineError is a line of an import that could not be read or written.
+type LineError struct {
+	Line int
+	Err  error
+}
+
+func (e *LineError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Err)
+}
+
+func (e *LineError) Unwrap() error {
+	return e.Err
+}
+
+// ImportResult is the result of an import.
+type ImportResult struct {
+	// Read is the number of documents read.
+	Read int
+	// Inserted is the number of documents inserted, including upserts.
+	Inserted int64
+	// Replaced is the number of existing documents replaced by upserts.
+	Replaced int64
+	// Errors are the lines that could not be read, were invalid, or could not be written.
+	Errors []*LineError
+}
+
+// Import reads documents from r, in the format of Export, and writes them to the collection in
+// bulk writes. Documents are decoded into T and validated, so that CSV cells are converted to the
+// types of the fields, but the model hooks are not called: timestamps are kept as they are. The
+// types of the CSV cells of other columns, and of all the columns of models that are not
+// structs, are inferred from the formats of Export, e.g. 24 hex digits are object ids. Lines
+// that cannot be read or written are reported in the Errors of the result, and the import goes
+// on; the error is only set when r cannot be read.
+//
+// Example:
+//
+//	res, err := s.Import(f, ImportOptions{Format: FormatCSV, Upsert: true})
+func (s *Store[T]) Import(r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	return s.ImportWithContext(mgm.Ctx(), r, opts)
+}
+
+// ImportWithContext is Import with a context, which carries the tenant of tenant stores.
+func (s *Store[T]) ImportWithContext(ctx context.Context, r io.Reader, opts ImportOptions) (*ImportResult, error) {
+	if opts.Format == "" {
+		opts.Format = FormatJSONLines
+	}
+	if _, err := ParseFormat(string(opts.Format)); err != nil {
+		return nil, err
+	}
+	if opts.BatchSize <= 0 {
+		opts.BatchSize = 1000
+	}
+
+	im := &importer[T]{store: s, ctx: ctx, opts: opts, res: &ImportResult{}}
+	var err error
+	if opts.Format == FormatCSV {
+		err = im.readCSV(r)
+	} else {
+		err = im.readJSON(r)
+	}
+	if err != nil {
+		return im.res, err
+	}
+	return im.res, im.flush()
+}
+
+// importer batches the documents of an import.
+type importer[T mgm.Model] struct {
+	store  *Store[T]
+	ctx    context.Context
+	opts   ImportOptions
+	res    *ImportResult
+	models []mongo.WriteModel
+	lines  []int
+}
+
+func (im *importer[T]) readJSON(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024) // BSON documents are up to 16MB
+	line := 0
+	for scanner.Scan() {
+		line++
+		data := scanner.Bytes()
+		if len(bytes.TrimSpace(data)) == 0 {
+			continue
+		}
+		o, err := decodeModel[T](func(v interface{}) error {
+			return bson.UnmarshalExtJSON(data, false, v)
+		})
+		if err := im.add(line, o, err); err != nil {
+			return err
+		}
+	}
+	return scanner.Err()
+}
+
+func (im *importer[T]) readCSV(r io.Reader) error {
+	cr := csv.NewReader(r)
+	columns, err := cr.Read()
+	if err == io.EOF {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	fields := map[string]modelField{}
+	if t := modelType[T](); t.Kind() == reflect.Struct {
+		fields = modelFields[T]()
+	}
+
+	for {
+		row, err := cr.Read()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			var perr *csv.ParseError
+			if !errors.As(err, &perr) {
+				return err
+			}
+			im.res.Read++
+			im.res.Errors = append(im.res.Errors, &LineError{Line: perr.Line, Err: perr.Err})
+			continue
+		}
+		line, _ := cr.FieldPos(0)
+
+		doc, err := csvDocument(columns, row, fields)
+		var o T
+		if err == nil {
+			o, err = decodeModel[T](func(v interface{}) error {
+				data, err := bson.Marshal(doc)
+				if err != nil {
+					return err
+				}
+				return bson.Unmarshal(data, v)
+			})
+		}
+		if err := im.add(line, o, err); err != nil {
+			return err
+		}
+	}
+}
+
+// add adds the document o of line to the batch, or reports err.
+func (im *importer[T]) add(line int, o T, err error) error {
+	im.res.Read++
+	if err == nil {
+		err = im.store.stampTenant(im.ctx, o)
+	}
+	if err == nil {
+		err = Validate(o)
+	}
+	if err != nil {
+		im.res.Errors = append(im.res.Errors, &LineError{Line: line, Err: err})
+		return nil
+	}
+
+	id := o.GetID()
+	if im.opts.Upsert && id != nil && id != primitive.NilObjectID {
+		filter, err := im.store.tenantFilter(im.ctx, bson.M{"_id": id})
+		if err != nil {
+			return err
+		}
+		im.models = append(im.models, mongo.NewReplaceOneModel().SetFilter(filter).SetReplacement(o).SetUpsert(true))
+	} else {
+		im.models = append(im.models, mongo.NewInsertOneModel().SetDocument(o))
+	}
+	im.lines = append(im.lines, line)
+
+	if len(im.models) >= im.opts.BatchSize {
+		return im.flush()
+	}
+	return nil
+}
+
+// flush writes the batch, reporting the documents that could not be written.
+func (im *importer[T]) flush() error {
+	if len(im.models) == 0 {
+		return nil
+	}
+	models, lines := im.models, im.lines
+	im.models, im.lines = nil, nil
+
+	err := im.store.observe(im.ctx, operation{name: "import"}, func(ctx context.Context) (int64, error) {
+		res, err := im.store.Collection.BulkWrite(ctx, models, options.BulkWrite().SetOrdered(false))
+		if res == nil {
+			return 0, err
+		}
+		im.res.Inserted += res.InsertedCount + res.UpsertedCount
+		im.res.Replaced += res.MatchedCount
+		return res.InsertedCount + res.UpsertedCount + res.ModifiedCount, err
+	})
+
+	var bwe mongo.BulkWriteException
+	if errors.As(err, &bwe) && bwe.WriteConcernError == nil {
+		for _, we := range bwe.WriteErrors {
+			im.res.Errors = append(im.res.Errors, &LineError{Line: lines[we.Index], Err: errors.New(we.Message)})
+		}
+		return nil
+	}
+	return err
+}
+
+// decodeModel returns a new T decoded by decode, which is given a pointer to decode into.
+func decodeModel[T mgm.Model](decode func(v interface{}) error) (T, error) {
+	var zero T
+	t := reflect.TypeOf((*T)(nil)).Elem()
+	if t.Kind() == reflect.Ptr {
+		v := reflect.New(t.Elem())
+		if err := decode(v.Interface()); err != nil {
+			return zero, err
+		}
+		return v.Interface().(T), nil
+	}
+	v := reflect.New(t)
+	if err := decode(v.Interface()); err != nil {
+		return zero, err
+	}
+	return v.Elem().Interface().(T), nil
+}
+
+// csvDocument returns the document of a CSV row. Cells are converted to the types of the fields
+// of the model, and the types of the cells of other columns are inferred, see csvInfer. Empty
+// cells are left out.
+func csvDocument(columns, row []string, fields map[string]modelField) (bson.D, error) {
+	doc := bson.D{}
+	for i, cell := range row {
+		if i >= len(columns) || cell == "" {
+			continue
+		}
+		path := columns[i]
+
+		value, err := csvCell(path, cell, fields)
+		if err != nil {
+			return nil, fmt.Errorf("column %s: %w", path, err)
+		}
+		doc = setPath(doc, strings.Split(path, "."), value)
+	}
+	return doc, nil
+}
+
+func csvCell(path, cell string, fields map[string]modelField) (interface{}, error) {
+	f, ok := fields[path]
+	if !ok {
+		return csvInfer(cell), nil
+	}
+
+	// the kind of known fields decides, so that strings like "[group] title" stay strings
+	structured := false
+	switch t := indirect(f.Type); t.Kind() {
+	case reflect.Interface:
+		structured = strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{")
+	case reflect.Slice, reflect.Array, reflect.Map, reflect.Struct:
+		structured = t != timeType && t != objectIDType
+	}
+	if structured {
+		return csvExtJSON(cell)
+	}
+	return coerce(f.Type, cell)
+}
+
+// csvInfer returns the value of a cell of a column that is not a field of the model, reversing
+// the formats of csvValue: Extended JSON arrays and documents, booleans, integers, floats, hex
+// object ids and RFC 3339 dates. Other cells, including arrays and documents that are not valid
+// Extended JSON, are strings.
+func csvInfer(cell string) interface{} {
+	if strings.HasPrefix(cell, "[") || strings.HasPrefix(cell, "{") {
+		if v, err := csvExtJSON(cell); err == nil {
+			return v
+		}
+		return cell
+	}
+	switch cell {
+	case "true":
+		return true
+	case "false":
+		return false
+	}
+	if strings.Trim(cell, "+-.0123456789eE") == "" {
+		if n, err := strconv.ParseInt(cell, 10, 64); err == nil {
+			if n >= math.MinInt32 && n <= math.MaxInt32 {
+				return int32(n)
+			}
+			return n
+		}
+		if n, err := strconv.ParseFloat(cell, 64); err == nil {
+			return n
+		}
+	}
+	if len(cell) == 24 {
+		if id, err := primitive.ObjectIDFromHex(cell); err == nil {
+			return id
+		}
+	}
+	if t, err := time.Parse(time.RFC3339Nano, cell); err == nil {
+		return t
+	}
+	return cell
+}
+
+// csvExtJSON returns the value of a cell holding a relaxed Extended JSON value.
+func csvExtJSON(cell string) (interface{}, error) {
+	doc := bson.D{}
+	if err := bson.UnmarshalExtJSON([]byte(`{"v":`+cell+`}`), false, &doc); err != nil {
+		return nil, err
+	}
+	return doc[0].Value, nil
+}
+
+// setPath sets the value of the dotted path keys in doc, creating the nested documents.
+func setPath(doc bson.D, keys []string, value interface{}) bson.D {
+	for i := range doc {
+		if doc[i].Key != keys[0] {
+			continue
+		}
+		if len(keys) == 1 {
+			doc[i].Value = value
+		} else if inner, ok := doc[i].Value.(bson.D); ok {
+			doc[i].Value = setPath(inner, keys[1:], value)
+		}
+		return doc
+	}
+	if len(keys) == 1 {
+		return append(doc, bson.E{Key: keys[0], Value: value})
+	}
+	return append(doc, bson.E{Key: keys[0], Value: setPath(bson.D{}, keys[1:], value)})
+}
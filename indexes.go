@@ -0,0 +1,77 @@
+package grimoire
+
+import (
+	"fmt"
+	"strings"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ParseIndexSpec parses index specs separated by semicolons, each a comma separated list of
+// fields with an optional direction: asc or 1 (the default), desc or -1, or text.
+//
+// Example:
+//
+//	ParseIndexSpec("status;created_at:desc;medium_id,num:-1;title:text")
+func ParseIndexSpec(spec string) ([]bson.D, error) {
+	indexes := []bson.D{}
+	for _, s := range strings.Split(spec, ";") {
+		keys := bson.D{}
+		for _, field := range strings.Split(s, ",") {
+			field = strings.TrimSpace(field)
+			if field == "" {
+				continue
+			}
+			name, dir, _ := strings.Cut(field, ":")
+			switch dir {
+			case "", "asc", "1":
+				keys = append(keys, bson.E{Key: name, Value: 1})
+			case "desc", "-1":
+				keys = append(keys, bson.E{Key: name, Value: -1})
+			case "text":
+				keys = append(keys, bson.E{Key: name, Value: "text"})
+			default:
+				return nil, fmt.Errorf("grimoire: index %q: invalid direction %q", strings.TrimSpace(s), dir)
+			}
+		}
+		if len(keys) > 0 {
+			indexes = append(indexes, keys)
+		}
+	}
+	return indexes, nil
+}
+
+// SameIndexKeys returns true when a and b are the keys of the same index. Directions are equal
+// whatever their numeric type, and text keys are equal to the _fts and _ftsx keys the server
+// lists for text indexes, whatever the fields and weights of the text index.
+func SameIndexKeys(a, b bson.D) bool {
+	a, b = normalizeIndexKeys(a), normalizeIndexKeys(b)
+	return len(a) == len(b) && isPrefix(a, b)
+}
+
+// normalizeIndexKeys returns keys as listed by the server: the text keys of an index are
+// replaced by a single pair of _fts and _ftsx keys.
+func normalizeIndexKeys(keys bson.D) bson.D {
+	list := make(bson.D, 0, len(keys))
+	text := false
+	for _, e := range keys {
+		if v, ok := e.Value.(string); !ok || v != "text" || e.Key == "_fts" {
+			list = append(list, e)
+			continue
+		}
+		if !text {
+			list = append(list, bson.E{Key: "_fts", Value: "text"}, bson.E{Key: "_ftsx", Value: 1})
+			text = true
+		}
+	}
+	return list
+}
+
+// indexKeyValue returns the value of an index key in a comparable form: directions as int64
+// and index types, like text or 2dsphere, as strings.
+func indexKeyValue(v interface{}) interface{} {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	return toInt64(v)
+}
@@ -0,0 +1,44 @@
+package grimoire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseIndexSpec(t *testing.T) {
+	indexes, err := ParseIndexSpec("status; created_at:desc ;name,age:-1;title:text;")
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.D{
+		{{Key: "status", Value: 1}},
+		{{Key: "created_at", Value: -1}},
+		{{Key: "name", Value: 1}, {Key: "age", Value: -1}},
+		{{Key: "title", Value: "text"}},
+	}, indexes)
+
+	_, err = ParseIndexSpec("status:up")
+	assert.Error(t, err)
+}
+
+func TestSameIndexKeys(t *testing.T) {
+	testCases := []struct {
+		name string
+		a    bson.D
+		b    bson.D
+		same bool
+	}{
+		{"numeric types", bson.D{{Key: "status", Value: int32(1)}}, bson.D{{Key: "status", Value: 1}}, true},
+		{"directions", bson.D{{Key: "status", Value: int32(1)}}, bson.D{{Key: "status", Value: -1}}, false},
+		{"length", bson.D{{Key: "status", Value: 1}}, bson.D{{Key: "status", Value: 1}, {Key: "kind", Value: 1}}, false},
+		{"text", bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}, bson.D{{Key: "title", Value: "text"}}, true},
+		{"text fields", bson.D{{Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}, bson.D{{Key: "title", Value: "text"}, {Key: "body", Value: "text"}}, true},
+		{"compound text", bson.D{{Key: "kind", Value: int32(1)}, {Key: "_fts", Value: "text"}, {Key: "_ftsx", Value: int32(1)}}, bson.D{{Key: "kind", Value: 1}, {Key: "title", Value: "text"}}, true},
+		{"index types", bson.D{{Key: "location", Value: "2dsphere"}}, bson.D{{Key: "location", Value: "text"}}, false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.same, SameIndexKeys(tc.a, tc.b))
+		})
+	}
+}
@@ -0,0 +1,221 @@
+package grimoire
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+// LoaderOptions configures a Loader, see Store.Loader.
+type LoaderOptions struct {
+	// Wait is how long a loader collects ids before querying them.
+	Wait time.Duration
+	// MaxBatch is the maximum number of ids in each query.
+	MaxBatch int
+}
+
+// DefaultLoaderOptions collects ids for 2 milliseconds, in batches of up to 1000.
+var DefaultLoaderOptions = LoaderOptions{
+	Wait:     2 * time.Millisecond,
+	MaxBatch: refBatchSize,
+}
+
+// Loader coalesces the GetByID calls made within a short window into a single $in query, and
+// caches the results. A loader is meant to live for a single request, and is safe for
+// concurrent use.
+type Loader[T mgm.Model] struct {
+	ctx   context.Context
+	opts  LoaderOptions
+	fetch func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]T, error)
+
+	mu    sync.Mutex
+	cache map[primitive.ObjectID]*loaderResult[T]
+	batch *loaderBatch[T]
+}
+
+type loaderResult[T mgm.Model] struct {
+	done  chan struct{}
+	value T
+	err   error
+}
+
+type loaderBatch[T mgm.Model] struct {
+	ids        []primitive.ObjectID
+	results    []*loaderResult[T]
+	timer      *time.Timer
+	dispatched bool
+}
+
+// Loader returns a loader of the documents of the store, which queries the store with ctx.
+// Zero options use the values of DefaultLoaderOptions.
+//
+// Example:
+//
+//	l := s.Loader(r.Context(), DefaultLoaderOptions)
+//	d, err := l.GetByID(id)
+func (s *Store[T]) Loader(ctx context.Context, opts LoaderOptions) *Loader[T] {
+	if opts.Wait <= 0 {
+		opts.Wait = DefaultLoaderOptions.Wait
+	}
+	if opts.MaxBatch <= 0 {
+		opts.MaxBatch = DefaultLoaderOptions.MaxBatch
+	}
+	return &Loader[T]{
+		ctx:   ctx,
+		opts:  opts,
+		fetch: s.getMany,
+		cache: map[primitive.ObjectID]*loaderResult[T]{},
+	}
+}
+
+// GetByID returns the document with id, waiting for the batch it is loaded in. The error is
+// mongo.ErrNoDocuments when there is no document with id.
+func (l *Loader[T]) GetByID(id primitive.ObjectID) (T, error) {
+	r := l.load(id)
+	<-r.done
+	return r.value, r.err
+}
+
+// Get returns the document with the hex id, see GetByID.
+func (l *Loader[T]) Get(id string) (T, error) {
+	oid, err := idFromHex(id)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+	return l.GetByID(oid)
+}
+
+// GetMany returns the documents with ids, in the same order, and an error for each id.
+func (l *Loader[T]) GetMany(ids []primitive.ObjectID) ([]T, []error) {
+	results := make([]*loaderResult[T], len(ids))
+	for i, id := range ids {
+		results[i] = l.load(id)
+	}
+
+	values := make([]T, len(ids))
+	errs := make([]error, len(ids))
+	for i, r := range results {
+		<-r.done
+		values[i], errs[i] = r.value, r.err
+	}
+	return values, errs
+}
+
+// Prime adds a document to the cache of the loader, unless id is already cached.
+func (l *Loader[T]) Prime(id primitive.ObjectID, value T) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if _, ok := l.cache[id]; ok {
+		return
+	}
+	r := &loaderResult[T]{done: make(chan struct{}), value: value}
+	close(r.done)
+	l.cache[id] = r
+}
+
+// Clear removes id from the cache of the loader, so that it is loaded again.
+func (l *Loader[T]) Clear(id primitive.ObjectID) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	delete(l.cache, id)
+}
+
+// load returns the result for id, adding id to the pending batch unless it is cached.
+func (l *Loader[T]) load(id primitive.ObjectID) *loaderResult[T] {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if r, ok := l.cache[id]; ok {
+		return r
+	}
+
+	r := &loaderResult[T]{done: make(chan struct{})}
+	l.cache[id] = r
+
+	b := l.batch
+	if b == nil {
+		b = &loaderBatch[T]{}
+		b.timer = time.AfterFunc(l.opts.Wait, func() { l.dispatch(b) })
+		l.batch = b
+	}
+	b.ids = append(b.ids, id)
+	b.results = append(b.results, r)
+	if len(b.ids) >= l.opts.MaxBatch {
+		b.timer.Stop()
+		l.batch = nil
+		go l.dispatch(b)
+	}
+	return r
+}
+
+// dispatch queries the ids of the batch b, once.
+func (l *Loader[T]) dispatch(b *loaderBatch[T]) {
+	l.mu.Lock()
+	if b.dispatched {
+		l.mu.Unlock()
+		return
+	}
+	b.dispatched = true
+	if l.batch == b {
+		l.batch = nil
+	}
+	l.mu.Unlock()
+
+	found, err := l.fetch(l.ctx, b.ids)
+	if err != nil {
+		// failed ids are not cached, so that they are loaded again
+		l.mu.Lock()
+		for i, id := range b.ids {
+			if l.cache[id] == b.results[i] {
+				delete(l.cache, id)
+			}
+		}
+		l.mu.Unlock()
+	}
+
+	for i, id := range b.ids {
+		r := b.results[i]
+		switch v, ok := found[id]; {
+		case err != nil:
+			r.err = err
+		case ok:
+			r.value = v
+		default:
+			r.err = mongo.ErrNoDocuments
+		}
+		close(r.done)
+	}
+}
+
+// getMany loads the documents with ids, keyed by id. Missing documents are left out.
+// NOTE: like FindByID, the default scopes do not apply, only the tenant of tenant stores.
+func (s *Store[T]) getMany(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]T, error) {
+	filter, err := s.tenantFilter(ctx, bson.M{"_id": bson.M{"$in": ids}})
+	if err != nil {
+		return nil, err
+	}
+
+	list := make([]T, 0, len(ids))
+	err = s.observe(ctx, operation{name: "find", filter: filter}, func(ctx context.Context) (int64, error) {
+		err := s.Collection.SimpleFindWithCtx(ctx, &list, filter)
+		return int64(len(list)), err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	found := make(map[primitive.ObjectID]T, len(list))
+	for _, o := range list {
+		if id, ok := o.GetID().(primitive.ObjectID); ok {
+			found[id] = o
+		}
+	}
+	return found, nil
+}
@@ -0,0 +1,156 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestStore_Loader(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	docs := map[primitive.ObjectID]*Download{}
+	ids := []primitive.ObjectID{}
+	for i := 0; i < 3; i++ {
+		d := &Download{Status: "done"}
+		d.ID = primitive.NewObjectID()
+		docs[d.ID] = d
+		ids = append(ids, d.ID)
+	}
+	missing := primitive.NewObjectID()
+
+	mu := sync.Mutex{}
+	calls := [][]primitive.ObjectID{}
+	fail := false
+	fetch := func(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]*Download, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		calls = append(calls, ids)
+		if fail {
+			return nil, errors.New("boom")
+		}
+		found := map[primitive.ObjectID]*Download{}
+		for _, id := range ids {
+			if d, ok := docs[id]; ok {
+				found[id] = d
+			}
+		}
+		return found, nil
+	}
+
+	l := s.Loader(context.Background(), LoaderOptions{Wait: 10 * time.Millisecond})
+	l.fetch = fetch
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			id := ids[i%len(ids)]
+			d, err := l.GetByID(id)
+			assert.NoError(t, err)
+			assert.Same(t, docs[id], d)
+		}(i)
+	}
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		_, err := l.GetByID(missing)
+		assert.True(t, errors.Is(err, mongo.ErrNoDocuments))
+	}()
+	wg.Wait()
+
+	if assert.Len(t, calls, 1, "coalesced") {
+		assert.Len(t, calls[0], 4, "deduplicated")
+	}
+
+	// cached
+	d, err := l.Get(ids[0].Hex())
+	assert.NoError(t, err)
+	assert.Same(t, docs[ids[0]], d)
+	assert.Len(t, calls, 1)
+
+	values, errs := l.GetMany([]primitive.ObjectID{ids[2], missing, ids[1]})
+	assert.Equal(t, []*Download{docs[ids[2]], nil, docs[ids[1]]}, values)
+	assert.NoError(t, errs[0])
+	assert.Error(t, errs[1])
+	assert.NoError(t, errs[2])
+	assert.Len(t, calls, 1)
+
+	// errors are returned to every caller, and not cached
+	fail = true
+	l.Clear(ids[0])
+	_, err = l.GetByID(ids[0])
+	assert.EqualError(t, err, "boom")
+	fail = false
+	d, err = l.GetByID(ids[0])
+	assert.NoError(t, err)
+	assert.Same(t, docs[ids[0]], d)
+	assert.Len(t, calls, 3)
+
+	primed := &Download{}
+	other := primitive.NewObjectID()
+	l.Prime(other, primed)
+	d, err = l.GetByID(other)
+	assert.NoError(t, err)
+	assert.Same(t, primed, d)
+
+	// full batches are queried without waiting
+	calls = nil
+	l = s.Loader(context.Background(), LoaderOptions{Wait: time.Hour, MaxBatch: 2})
+	l.fetch = fetch
+	values, errs = l.GetMany(append(ids, missing))
+	assert.Equal(t, []*Download{docs[ids[0]], docs[ids[1]], docs[ids[2]], nil}, values)
+	assert.Error(t, errs[3])
+	if assert.Len(t, calls, 2) {
+		assert.Len(t, calls[0], 2)
+		assert.Len(t, calls[1], 2)
+	}
+}
+
+// Loaders and preloads find documents by id, like FindByID, so default scopes do not hide them.
+func TestStore_LoaderDefaultScopes(t *testing.T) {
+	media, err := New[*Medium]("mongodb://localhost:27017", "grimoire", "media")
+	assert.NoError(t, err)
+	assert.NotNil(t, media)
+	downloads, err := New[*Download]("mongodb://localhost:27017", "grimoire", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, downloads)
+
+	media.RegisterScope("active", func(q *QueryBuilder[*Medium]) *QueryBuilder[*Medium] {
+		return q.Where("active", true)
+	})
+	assert.NoError(t, media.SetDefaultScopes("active"))
+	assert.NoError(t, downloads.SetRef("Medium", media))
+
+	m := &Medium{Title: "inactive", Active: false}
+	assert.NoError(t, media.Save(m))
+	d := &Download{MediumId: m.ID, Status: "done"}
+	assert.NoError(t, downloads.Save(d))
+
+	count, err := media.Query().Where("_id", m.ID).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(0), count, "hidden by the default scope")
+	assert.NoError(t, media.FindByID(m.ID, &Medium{}))
+
+	found, err := media.Loader(context.Background(), LoaderOptions{}).GetByID(m.ID)
+	if assert.NoError(t, err) {
+		assert.Equal(t, "inactive", found.Title)
+	}
+
+	list, err := downloads.Query().Where("_id", d.ID).Preload("Medium").Run()
+	if assert.NoError(t, err) && assert.Len(t, list, 1) && assert.NotNil(t, list[0].Medium) {
+		assert.Equal(t, m.ID, list[0].Medium.ID)
+	}
+
+	assert.NoError(t, downloads.Delete(d))
+	assert.NoError(t, media.Delete(m))
+}
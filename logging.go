@@ -0,0 +1,84 @@
+package grimoire
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// LogOptions configures the operation log, see SetLogger.
+type LogOptions struct {
+	// Level is the level of the record logged for each operation.
+	Level slog.Level
+	// ErrorLevel is the level of the record logged for failed operations.
+	ErrorLevel slog.Level
+	// SlowThreshold is the duration above which an operation is logged at warn level, with the
+	// full shape of the query. Zero disables the slow query log.
+	SlowThreshold time.Duration
+}
+
+// DefaultLogOptions logs operations at debug level, failures at error level, and operations
+// slower than one second at warn level.
+var DefaultLogOptions = LogOptions{
+	Level:         slog.LevelDebug,
+	ErrorLevel:    slog.LevelError,
+	SlowThreshold: time.Second,
+}
+
+// logging holds the logger of a store.
+type logging struct {
+	logger *slog.Logger
+	opts   LogOptions
+}
+
+// SetLogger logs each Store and QueryBuilder operation to logger, with the collection, operation,
+// filter (without values), duration and result count. Strict mode warnings are also logged to
+// logger. A nil logger disables the operation log.
+//
+// Example:
+//
+//	s.SetLogger(slog.Default(), DefaultLogOptions)
+func (s *Store[T]) SetLogger(logger *slog.Logger, opts LogOptions) {
+	if logger == nil {
+		s.logging = nil
+		return
+	}
+	s.logging = &logging{logger: logger, opts: opts}
+}
+
+// log logs the operation op on collection, which took elapsed and returned or affected n
+// documents.
+func (l *logging) log(ctx context.Context, collection string, op operation, elapsed time.Duration, n int64, err error) {
+	level := l.opts.Level
+	msg := "grimoire: " + op.name
+	slow := err == nil && l.opts.SlowThreshold > 0 && elapsed > l.opts.SlowThreshold
+	switch {
+	case err != nil:
+		level = l.opts.ErrorLevel
+	case slow:
+		level = slog.LevelWarn
+		msg = "grimoire: slow " + op.name
+	}
+	if !l.logger.Enabled(ctx, level) {
+		return
+	}
+
+	attrs := []slog.Attr{
+		slog.String("collection", collection),
+		slog.String("operation", op.name),
+	}
+	if op.filter != nil {
+		attrs = append(attrs, slog.String("filter", op.shape()))
+	}
+	attrs = append(attrs,
+		slog.Duration("duration", elapsed),
+		slog.Int64("results", n),
+	)
+	if slow && op.query != nil {
+		attrs = append(attrs, slog.String("query", op.query()))
+	}
+	if err != nil {
+		attrs = append(attrs, slog.String("error", err.Error()))
+	}
+	l.logger.LogAttrs(ctx, level, msg, attrs...)
+}
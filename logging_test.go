@@ -0,0 +1,70 @@
+package grimoire
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStore_Logger(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	buf := &bytes.Buffer{}
+	logger := slog.New(slog.NewJSONHandler(buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	s.SetLogger(logger, LogOptions{Level: slog.LevelDebug, ErrorLevel: slog.LevelError, SlowThreshold: 10 * time.Millisecond})
+
+	q := s.Query().Where("status", "done").Desc("created_at")
+	err = s.observe(context.Background(), q.operation("find", q.filter()), func(ctx context.Context) (int64, error) {
+		return 3, nil
+	})
+	assert.NoError(t, err)
+	err = s.observe(context.Background(), q.operation("find", q.filter()), func(ctx context.Context) (int64, error) {
+		time.Sleep(20 * time.Millisecond)
+		return 1, nil
+	})
+	assert.NoError(t, err)
+	err = s.observe(context.Background(), operation{name: "delete", filter: bson.M{"_id": "abc"}}, func(ctx context.Context) (int64, error) {
+		return 0, errors.New("boom")
+	})
+	assert.Error(t, err)
+
+	records := []map[string]interface{}{}
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		r := map[string]interface{}{}
+		assert.NoError(t, json.Unmarshal([]byte(line), &r))
+		records = append(records, r)
+	}
+	if assert.Len(t, records, 3) {
+		assert.Equal(t, "DEBUG", records[0]["level"])
+		assert.Equal(t, "grimoire: find", records[0]["msg"])
+		assert.Equal(t, "downloads", records[0]["collection"])
+		assert.Equal(t, `{"$and": [{"status": {"$eq": "?"}}]}`, records[0]["filter"])
+		assert.Equal(t, float64(3), records[0]["results"])
+		assert.NotContains(t, records[0], "query")
+
+		assert.Equal(t, "WARN", records[1]["level"])
+		assert.Equal(t, "grimoire: slow find", records[1]["msg"])
+		assert.Equal(t, `db.downloads.find({"$and": [{"status": {"$eq": "?"}}]}).sort({"created_at": -1}).limit(25)`, records[1]["query"])
+
+		assert.Equal(t, "ERROR", records[2]["level"])
+		assert.Equal(t, "boom", records[2]["error"])
+	}
+
+	buf.Reset()
+	s.SetLogger(logger, LogOptions{Level: slog.LevelDebug - 4})
+	err = s.observe(context.Background(), q.operation("find", q.filter()), func(ctx context.Context) (int64, error) {
+		return 0, nil
+	})
+	assert.NoError(t, err)
+	assert.Empty(t, buf.String(), "below handler level")
+}
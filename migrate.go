@@ -0,0 +1,322 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+var (
+	// ErrMigrationLocked is returned when another instance holds the migration lock.
+	ErrMigrationLocked = errors.New("migrations are locked by another instance")
+	// ErrIrreversibleMigration is returned when rolling back a migration without a Down function.
+	ErrIrreversibleMigration = errors.New("migration cannot be rolled back")
+	// ErrUnknownMigration is returned when rolling back an applied migration that is not
+	// registered.
+	ErrUnknownMigration = errors.New("unknown migration")
+)
+
+// Migration is a versioned change to the database. Migrations are applied in the order of their
+// versions, which are usually timestamps such as 20240521093000.
+type Migration struct {
+	Version int64
+	Name    string
+	Up      func(ctx context.Context, db *mongo.Database) error
+	// Down reverts Up. Migrations without Down cannot be rolled back.
+	Down func(ctx context.Context, db *mongo.Database) error
+}
+
+// MigrationStatus describes a registered or applied migration.
+type MigrationStatus struct {
+	Version   int64
+	Name      string
+	Applied   bool
+	AppliedAt time.Time
+	// Missing is true when the migration is applied but not registered.
+	Missing bool
+}
+
+// migrationRecord is the document recording an applied migration.
+type migrationRecord struct {
+	Version   int64     `bson:"_id"`
+	Name      string    `bson:"name"`
+	AppliedAt time.Time `bson:"applied_at"`
+}
+
+// Migrator applies migrations to a database, recording the applied versions in a collection.
+// A lock document prevents several instances from migrating at the same time.
+type Migrator struct {
+	db         *mongo.Database
+	collection string
+	migrations []Migration
+	dryRun     bool
+	owner      string
+	lockTTL    time.Duration
+}
+
+// NewMigrator creates a migrator for db, which records the applied migrations in the migrations
+// collection.
+//
+// Example:
+//
+//	m := NewMigrator(db)
+//	err := m.Register(Migration{Version: 20240521093000, Name: "rename status", Up: up, Down: down})
+func NewMigrator(db *mongo.Database) *Migrator {
+	host, _ := os.Hostname()
+	return &Migrator{
+		db:         db,
+		collection: "migrations",
+		owner:      fmt.Sprintf("%s:%d:%d", host, os.Getpid(), time.Now().UnixNano()),
+		lockTTL:    10 * time.Minute,
+	}
+}
+
+// Migrator creates a migrator for the database of the store, see NewMigrator.
+func (s *Store[T]) Migrator() *Migrator {
+	return NewMigrator(s.Database)
+}
+
+// SetCollection sets the collection recording the applied migrations. The lock is kept in the
+// collection with the "_lock" suffix.
+func (m *Migrator) SetCollection(name string) {
+	m.collection = name
+}
+
+// SetDryRun enables dry-run mode, in which Up and Down return the migrations they would apply or
+// roll back, without running them.
+func (m *Migrator) SetDryRun(dryRun bool) {
+	m.dryRun = dryRun
+}
+
+// SetLockTTL sets how long the migration lock is held before another instance can take it over,
+// in case the instance holding it died. It should be longer than the slowest migration.
+func (m *Migrator) SetLockTTL(ttl time.Duration) {
+	m.lockTTL = ttl
+}
+
+// Register adds migrations to the migrator. Versions should be positive and unique.
+func (m *Migrator) Register(migrations ...Migration) error {
+	for _, mg := range migrations {
+		if mg.Version <= 0 {
+			return fmt.Errorf("grimoire: migration %q: version should be positive", mg.Name)
+		}
+		if mg.Up == nil {
+			return fmt.Errorf("grimoire: migration %d: no Up function", mg.Version)
+		}
+		for _, other := range m.migrations {
+			if other.Version == mg.Version {
+				return fmt.Errorf("grimoire: migration %d: duplicate version", mg.Version)
+			}
+		}
+		m.migrations = append(m.migrations, mg)
+	}
+	sort.Slice(m.migrations, func(i, j int) bool {
+		return m.migrations[i].Version < m.migrations[j].Version
+	})
+	return nil
+}
+
+// Status returns the registered and applied migrations, ordered by version.
+func (m *Migrator) Status(ctx context.Context) ([]MigrationStatus, error) {
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return migrationStatus(m.migrations, applied), nil
+}
+
+// Up applies the pending migrations, in order, and returns them. It stops at the first error.
+func (m *Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.UpTo(ctx, 0)
+}
+
+// UpTo applies the pending migrations up to and including version, or all of them when
+// version is zero, in order, and returns them. It stops at the first error.
+func (m *Migrator) UpTo(ctx context.Context, version int64) ([]Migration, error) {
+	return m.run(ctx, func(applied map[int64]migrationRecord) ([]Migration, error) {
+		return planUp(m.migrations, applied, version), nil
+	}, func(ctx context.Context, mg Migration) error {
+		if err := mg.Up(ctx, m.db); err != nil {
+			return err
+		}
+		record := migrationRecord{Version: mg.Version, Name: mg.Name, AppliedAt: time.Now().UTC()}
+		_, err := m.db.Collection(m.collection).InsertOne(ctx, record)
+		return err
+	})
+}
+
+// Down rolls back the last applied migration, and returns it.
+func (m *Migrator) Down(ctx context.Context) ([]Migration, error) {
+	return m.run(ctx, func(applied map[int64]migrationRecord) ([]Migration, error) {
+		latest := int64(0)
+		for v := range applied {
+			if v > latest {
+				latest = v
+			}
+		}
+		if latest == 0 {
+			return nil, nil
+		}
+		last := map[int64]migrationRecord{latest: applied[latest]}
+		return planDown(m.migrations, last, 0)
+	}, m.down)
+}
+
+// DownTo rolls back the applied migrations with a version greater than version, in reverse
+// order, and returns them. It stops at the first error.
+func (m *Migrator) DownTo(ctx context.Context, version int64) ([]Migration, error) {
+	return m.run(ctx, func(applied map[int64]migrationRecord) ([]Migration, error) {
+		return planDown(m.migrations, applied, version)
+	}, m.down)
+}
+
+func (m *Migrator) down(ctx context.Context, mg Migration) error {
+	if err := mg.Down(ctx, m.db); err != nil {
+		return err
+	}
+	_, err := m.db.Collection(m.collection).DeleteOne(ctx, bson.M{"_id": mg.Version})
+	return err
+}
+
+// run plans the migrations to run with plan and runs each of them with f, holding the lock.
+func (m *Migrator) run(ctx context.Context, plan func(map[int64]migrationRecord) ([]Migration, error), f func(context.Context, Migration) error) ([]Migration, error) {
+	if !m.dryRun {
+		if err := m.lock(ctx); err != nil {
+			return nil, err
+		}
+		defer m.unlock(ctx)
+	}
+
+	applied, err := m.applied(ctx)
+	if err != nil {
+		return nil, err
+	}
+	list, err := plan(applied)
+	if err != nil || m.dryRun {
+		return list, err
+	}
+
+	for i, mg := range list {
+		if err := f(ctx, mg); err != nil {
+			return list[:i], fmt.Errorf("grimoire: migration %d %s: %w", mg.Version, mg.Name, err)
+		}
+	}
+	return list, nil
+}
+
+// applied returns the applied migrations, keyed by version.
+func (m *Migrator) applied(ctx context.Context) (map[int64]migrationRecord, error) {
+	cursor, err := m.db.Collection(m.collection).Find(ctx, bson.M{})
+	if err != nil {
+		return nil, err
+	}
+	records := []migrationRecord{}
+	if err := cursor.All(ctx, &records); err != nil {
+		return nil, err
+	}
+
+	applied := make(map[int64]migrationRecord, len(records))
+	for _, r := range records {
+		applied[r.Version] = r
+	}
+	return applied, nil
+}
+
+// lock takes the migration lock, or takes over a lock that has expired.
+func (m *Migrator) lock(ctx context.Context) error {
+	now := time.Now()
+	filter := bson.M{
+		"_id": "lock",
+		"$or": bson.A{
+			bson.M{"owner": m.owner},
+			bson.M{"expires_at": bson.M{"$lt": now}},
+		},
+	}
+	update := bson.M{"$set": bson.M{"owner": m.owner, "expires_at": now.Add(m.lockTTL)}}
+	_, err := m.db.Collection(m.collection+"_lock").UpdateOne(ctx, filter, update, options.Update().SetUpsert(true))
+	if mongo.IsDuplicateKeyError(err) {
+		return fmt.Errorf("grimoire: %w", ErrMigrationLocked)
+	}
+	return err
+}
+
+func (m *Migrator) unlock(ctx context.Context) {
+	_, _ = m.db.Collection(m.collection+"_lock").DeleteOne(ctx, bson.M{"_id": "lock", "owner": m.owner})
+}
+
+// planUp returns the migrations that are not applied, up to version, in order.
+func planUp(migrations []Migration, applied map[int64]migrationRecord, version int64) []Migration {
+	list := []Migration{}
+	for _, mg := range migrations {
+		if version > 0 && mg.Version > version {
+			break
+		}
+		if _, ok := applied[mg.Version]; !ok {
+			list = append(list, mg)
+		}
+	}
+	return list
+}
+
+// planDown returns the applied migrations with a version greater than version, in reverse order.
+func planDown(migrations []Migration, applied map[int64]migrationRecord, version int64) ([]Migration, error) {
+	registered := make(map[int64]Migration, len(migrations))
+	for _, mg := range migrations {
+		registered[mg.Version] = mg
+	}
+
+	versions := make([]int64, 0, len(applied))
+	for v := range applied {
+		if v > version {
+			versions = append(versions, v)
+		}
+	}
+	sort.Slice(versions, func(i, j int) bool { return versions[i] > versions[j] })
+
+	list := make([]Migration, 0, len(versions))
+	for _, v := range versions {
+		mg, ok := registered[v]
+		if !ok {
+			return nil, fmt.Errorf("grimoire: migration %d %s: %w", v, applied[v].Name, ErrUnknownMigration)
+		}
+		if mg.Down == nil {
+			return nil, fmt.Errorf("grimoire: migration %d %s: %w", v, mg.Name, ErrIrreversibleMigration)
+		}
+		list = append(list, mg)
+	}
+	return list, nil
+}
+
+// migrationStatus combines the registered and applied migrations.
+func migrationStatus(migrations []Migration, applied map[int64]migrationRecord) []MigrationStatus {
+	list := make([]MigrationStatus, 0, len(migrations))
+	for _, mg := range migrations {
+		st := MigrationStatus{Version: mg.Version, Name: mg.Name}
+		if r, ok := applied[mg.Version]; ok {
+			st.Applied = true
+			st.AppliedAt = r.AppliedAt
+		}
+		list = append(list, st)
+	}
+	for v, r := range applied {
+		found := false
+		for _, mg := range migrations {
+			if mg.Version == v {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, MigrationStatus{Version: v, Name: r.Name, Applied: true, AppliedAt: r.AppliedAt, Missing: true})
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Version < list[j].Version })
+	return list
+}
@@ -0,0 +1,129 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+	"time"
+)
+
+// Command runs the migration command in args for programs that register their migrations, as
+// migrations are Go functions which only the program registering them can run. The commands are:
+//   - status: list the registered and applied migrations
+//   - up: apply the pending migrations
+//   - up-to VERSION: apply the pending migrations up to and including VERSION
+//   - down: roll back the last applied migration
+//   - down-to VERSION: roll back the applied migrations with a greater version than VERSION
+//
+// The -dry-run flag lists the migrations that up and down would run, without running them.
+// The output is written to out.
+//
+// Example:
+//
+//	m := grimoire.NewMigrator(db)
+//	if err := m.Register(migrations...); err != nil {
+//		log.Fatal(err)
+//	}
+//	if err := m.Command(ctx, os.Args[1:], os.Stdout); err != nil {
+//		log.Fatal(err)
+//	}
+func (m *Migrator) Command(ctx context.Context, args []string, out io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	fs.SetOutput(out)
+	dryRun := fs.Bool("dry-run", false, "list the migrations to run, without running them")
+	fs.Usage = func() {
+		fmt.Fprintln(out, "usage: migrate [-dry-run] status|up|up-to VERSION|down|down-to VERSION")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	name, version, err := parseMigrateArgs(fs.Args())
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+	if name == "status" {
+		return m.printStatus(ctx, out)
+	}
+
+	dry := m.dryRun
+	m.SetDryRun(dry || *dryRun)
+	defer m.SetDryRun(dry)
+
+	var list []Migration
+	verb := "applied"
+	switch name {
+	case "up":
+		list, err = m.Up(ctx)
+	case "up-to":
+		list, err = m.UpTo(ctx, version)
+	case "down":
+		verb = "rolled back"
+		list, err = m.Down(ctx)
+	case "down-to":
+		verb = "rolled back"
+		list, err = m.DownTo(ctx, version)
+	}
+	if m.dryRun {
+		verb = "would have " + verb
+	}
+	for _, mg := range list {
+		fmt.Fprintf(out, "%s %d %s\n", verb, mg.Version, mg.Name)
+	}
+	if err == nil && len(list) == 0 {
+		fmt.Fprintln(out, "nothing to migrate")
+	}
+	return err
+}
+
+// parseMigrateArgs returns the command and version of the arguments of Command.
+func parseMigrateArgs(args []string) (string, int64, error) {
+	if len(args) == 0 {
+		return "", 0, errors.New("grimoire: no migrate command")
+	}
+	name := args[0]
+	switch name {
+	case "status", "up", "down":
+		if len(args) != 1 {
+			return "", 0, fmt.Errorf("grimoire: %s takes no arguments", name)
+		}
+		return name, 0, nil
+	case "up-to", "down-to":
+		if len(args) != 2 {
+			return "", 0, fmt.Errorf("grimoire: %s takes a version", name)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil || version < 0 || (name == "up-to" && version == 0) {
+			return "", 0, fmt.Errorf("grimoire: %s: invalid version %q", name, args[1])
+		}
+		return name, version, nil
+	}
+	return "", 0, fmt.Errorf("grimoire: unknown migrate command %q", name)
+}
+
+func (m *Migrator) printStatus(ctx context.Context, out io.Writer) error {
+	status, err := m.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATE\tAPPLIED AT")
+	for _, st := range status {
+		state, at := "pending", "-"
+		if st.Applied {
+			state, at = "applied", st.AppliedAt.Local().Format(time.RFC3339)
+		}
+		if st.Missing {
+			state = "missing"
+		}
+		fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", st.Version, st.Name, state, at)
+	}
+	return w.Flush()
+}
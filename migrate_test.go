@@ -0,0 +1,111 @@
+package grimoire
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo"
+)
+
+func TestMigrator_Register(t *testing.T) {
+	noop := func(ctx context.Context, db *mongo.Database) error { return nil }
+
+	m := NewMigrator(nil)
+	assert.NoError(t, m.Register(
+		Migration{Version: 3, Name: "three", Up: noop},
+		Migration{Version: 1, Name: "one", Up: noop, Down: noop},
+	))
+	assert.NoError(t, m.Register(Migration{Version: 2, Name: "two", Up: noop, Down: noop}))
+	assert.Equal(t, []int64{1, 2, 3}, []int64{m.migrations[0].Version, m.migrations[1].Version, m.migrations[2].Version})
+
+	assert.Error(t, m.Register(Migration{Version: 2, Name: "again", Up: noop}), "duplicate")
+	assert.Error(t, m.Register(Migration{Version: 0, Name: "zero", Up: noop}), "version")
+	assert.Error(t, m.Register(Migration{Version: 4, Name: "no up"}), "up")
+}
+
+func TestMigrator_Plan(t *testing.T) {
+	noop := func(ctx context.Context, db *mongo.Database) error { return nil }
+	migrations := []Migration{
+		{Version: 1, Name: "one", Up: noop, Down: noop},
+		{Version: 2, Name: "two", Up: noop},
+		{Version: 3, Name: "three", Up: noop, Down: noop},
+		{Version: 4, Name: "four", Up: noop, Down: noop},
+	}
+	versions := func(list []Migration) []int64 {
+		out := []int64{}
+		for _, mg := range list {
+			out = append(out, mg.Version)
+		}
+		return out
+	}
+
+	now := time.Now()
+	applied := map[int64]migrationRecord{
+		1: {Version: 1, Name: "one", AppliedAt: now},
+		3: {Version: 3, Name: "three", AppliedAt: now},
+	}
+	assert.Equal(t, []int64{2, 4}, versions(planUp(migrations, applied, 0)))
+	assert.Equal(t, []int64{2}, versions(planUp(migrations, applied, 3)))
+
+	list, err := planDown(migrations, applied, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3}, versions(list))
+	list, err = planDown(migrations, applied, 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []int64{3, 1}, versions(list))
+
+	applied[2] = migrationRecord{Version: 2, Name: "two"}
+	_, err = planDown(migrations, applied, 0)
+	assert.True(t, errors.Is(err, ErrIrreversibleMigration))
+
+	applied[9] = migrationRecord{Version: 9, Name: "removed"}
+	_, err = planDown(migrations, applied, 4)
+	assert.True(t, errors.Is(err, ErrUnknownMigration))
+
+	status := migrationStatus(migrations, applied)
+	if assert.Len(t, status, 5) {
+		assert.True(t, status[0].Applied)
+		assert.False(t, status[3].Applied)
+		assert.Equal(t, MigrationStatus{Version: 9, Name: "removed", Applied: true, Missing: true}, status[4])
+	}
+}
+
+func TestMigrator_CommandArgs(t *testing.T) {
+	testCases := []struct {
+		args    []string
+		name    string
+		version int64
+		err     bool
+	}{
+		{args: []string{"status"}, name: "status"},
+		{args: []string{"up"}, name: "up"},
+		{args: []string{"down"}, name: "down"},
+		{args: []string{"up-to", "20240521093000"}, name: "up-to", version: 20240521093000},
+		{args: []string{"down-to", "0"}, name: "down-to"},
+		{args: []string{}, err: true},
+		{args: []string{"sideways"}, err: true},
+		{args: []string{"up", "1"}, err: true},
+		{args: []string{"up-to"}, err: true},
+		{args: []string{"up-to", "0"}, err: true},
+		{args: []string{"down-to", "abc"}, err: true},
+	}
+	for _, tc := range testCases {
+		name, version, err := parseMigrateArgs(tc.args)
+		if tc.err {
+			assert.Error(t, err, tc.args)
+			continue
+		}
+		assert.NoError(t, err, tc.args)
+		assert.Equal(t, tc.name, name)
+		assert.Equal(t, tc.version, version)
+	}
+
+	buf := &bytes.Buffer{}
+	err := NewMigrator(nil).Command(context.Background(), []string{"-dry-run", "sideways"}, buf)
+	assert.ErrorContains(t, err, `unknown migrate command "sideways"`)
+	assert.Contains(t, buf.String(), "usage: migrate")
+}
@@ -0,0 +1,276 @@
+package grimoire
+
+import (
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+// SetReadPreference sets the read preference of all operations of the store, overriding the
+// read preference of the client.
+// NOTE: like the other setters of the store, it should be called when setting the store up,
+// before queries run concurrently. Use WithReadPreference to change it afterwards.
+//
+// Example:
+//
+//	s.SetReadPreference(readpref.SecondaryPreferred())
+func (s *Store[T]) SetReadPreference(rp *readpref.ReadPref) error {
+	return s.setCollectionOptions(options.Collection().SetReadPreference(rp))
+}
+
+// SetReadConcern sets the read concern of all operations of the store, overriding the read
+// concern of the client.
+// NOTE: it should be called when setting the store up, use WithReadConcern afterwards.
+//
+// Example:
+//
+//	s.SetReadConcern(readconcern.Majority())
+func (s *Store[T]) SetReadConcern(rc *readconcern.ReadConcern) error {
+	return s.setCollectionOptions(options.Collection().SetReadConcern(rc))
+}
+
+// SetWriteConcern sets the write concern of all operations of the store, overriding the write
+// concern of the client.
+// NOTE: it should be called when setting the store up, use WithWriteConcern afterwards.
+//
+// Example:
+//
+//	s.SetWriteConcern(writeconcern.Majority())
+func (s *Store[T]) SetWriteConcern(wc *writeconcern.WriteConcern) error {
+	return s.setCollectionOptions(options.Collection().SetWriteConcern(wc))
+}
+
+// WithReadPreference returns a copy of the store with the read preference, leaving the store
+// unchanged, so that it can be used while queries of the store run.
+//
+// Example:
+//
+//	reports, err := s.WithReadPreference(readpref.SecondaryPreferred())
+func (s *Store[T]) WithReadPreference(rp *readpref.ReadPref) (*Store[T], error) {
+	return s.withCollectionOptions(options.Collection().SetReadPreference(rp))
+}
+
+// WithReadConcern returns a copy of the store with the read concern, see WithReadPreference.
+func (s *Store[T]) WithReadConcern(rc *readconcern.ReadConcern) (*Store[T], error) {
+	return s.withCollectionOptions(options.Collection().SetReadConcern(rc))
+}
+
+// WithWriteConcern returns a copy of the store with the write concern, see WithReadPreference.
+func (s *Store[T]) WithWriteConcern(wc *writeconcern.WriteConcern) (*Store[T], error) {
+	return s.withCollectionOptions(options.Collection().SetWriteConcern(wc))
+}
+
+func (s *Store[T]) setCollectionOptions(o *options.CollectionOptions) error {
+	c, err := s.Collection.Clone(o)
+	if err != nil {
+		return err
+	}
+	s.Collection = &mgm.Collection{Collection: c}
+	return nil
+}
+
+func (s *Store[T]) withCollectionOptions(o *options.CollectionOptions) (*Store[T], error) {
+	c, err := s.Collection.Clone(o)
+	if err != nil {
+		return nil, err
+	}
+	return s.clone(&mgm.Collection{Collection: c}), nil
+}
+
+// clone returns a copy of the store using the collection coll. The copy has its own scopes and
+// references, and shares the index advisor, instrumentation and logger of the store.
+func (s *Store[T]) clone(coll *mgm.Collection) *Store[T] {
+	c := &Store[T]{
+		Client:          s.Client,
+		Database:        s.Database,
+		Collection:      coll,
+		queryDefaults:   s.queryDefaults,
+		tenantField:     s.tenantField,
+		strict:          s.strict,
+		instrumentation: s.instrumentation,
+		logging:         s.logging,
+	}
+	c.advisor.Store(s.advisor.Load())
+
+	s.scopesMu.RLock()
+	defer s.scopesMu.RUnlock()
+	if s.scopes != nil {
+		c.scopes = make(map[string]Scope[T], len(s.scopes))
+		for name, scope := range s.scopes {
+			c.scopes[name] = scope
+		}
+	}
+	c.defaultScopes = append([]string(nil), s.defaultScopes...)
+	if s.refs != nil {
+		c.refs = make(map[string]*reference, len(s.refs))
+		for name, ref := range s.refs {
+			c.refs[name] = ref
+		}
+	}
+	return c
+}
+
+// ReadPreference sets the read preference of the query, overriding the read preference of the
+// store.
+//
+// Example:
+//
+//	ReadPreference(readpref.SecondaryPreferred())
+func (q *QueryBuilder[T]) ReadPreference(rp *readpref.ReadPref) *QueryBuilder[T] {
+	q = q.mutable()
+	q.readPref = rp
+	return q
+}
+
+// ReadConcern sets the read concern of the query, overriding the read concern of the store.
+//
+// Example:
+//
+//	ReadConcern(readconcern.Majority())
+func (q *QueryBuilder[T]) ReadConcern(rc *readconcern.ReadConcern) *QueryBuilder[T] {
+	q = q.mutable()
+	q.readConcern = rc
+	return q
+}
+
+// WriteConcern sets the write concern of DeleteMany, overriding the write concern of the store.
+//
+// Example:
+//
+//	WriteConcern(writeconcern.Majority())
+func (q *QueryBuilder[T]) WriteConcern(wc *writeconcern.WriteConcern) *QueryBuilder[T] {
+	q = q.mutable()
+	q.writeConcern = wc
+	return q
+}
+
+// Hint forces the query to use an index, given by name or by keys.
+//
+// Example:
+//
+//	Hint("status_1_created_at_-1")
+//	Hint(bson.D{{Key: "status", Value: 1}})
+func (q *QueryBuilder[T]) Hint(index interface{}) *QueryBuilder[T] {
+	q = q.mutable()
+	q.hint = index
+	return q
+}
+
+// Collation sets the collation of the query.
+//
+// Example:
+//
+//	Collation(&options.Collation{Locale: "fr"})
+func (q *QueryBuilder[T]) Collation(c *options.Collation) *QueryBuilder[T] {
+	q = q.mutable()
+	q.collation = c
+	return q
+}
+
+// MaxTime limits the time the server spends executing the query. DeleteMany has no server
+// side limit, and uses a context deadline instead.
+//
+// Example:
+//
+//	MaxTime(5 * time.Second)
+func (q *QueryBuilder[T]) MaxTime(d time.Duration) *QueryBuilder[T] {
+	q = q.mutable()
+	q.maxTime = d
+	return q
+}
+
+// AllowDiskUse allows the server to write temporary data to disk when sorting large results.
+func (q *QueryBuilder[T]) AllowDiskUse() *QueryBuilder[T] {
+	q = q.mutable()
+	q.allowDiskUse = true
+	return q
+}
+
+// BatchSize sets the number of documents returned in each batch of the cursor.
+//
+// Example:
+//
+//	BatchSize(500)
+func (q *QueryBuilder[T]) BatchSize(n int32) *QueryBuilder[T] {
+	q = q.mutable()
+	q.batchSize = n
+	return q
+}
+
+// Comment attaches a comment to the query, which appears in the profiler and server logs.
+//
+// Example:
+//
+//	Comment("nightly report")
+func (q *QueryBuilder[T]) Comment(comment string) *QueryBuilder[T] {
+	q = q.mutable()
+	q.comment = comment
+	return q
+}
+
+// NoCursorTimeout prevents the server from closing the cursor of the query after a period of
+// inactivity.
+func (q *QueryBuilder[T]) NoCursorTimeout() *QueryBuilder[T] {
+	q = q.mutable()
+	q.noCursorTimeout = true
+	return q
+}
+
+// collection returns the collection of the store, with the read preference, read concern and
+// write concern of the query.
+func (q *QueryBuilder[T]) collection() (*mgm.Collection, error) {
+	if q.readPref == nil && q.readConcern == nil && q.writeConcern == nil {
+		return q.store.Collection, nil
+	}
+
+	o := options.Collection()
+	if q.readPref != nil {
+		o.SetReadPreference(q.readPref)
+	}
+	if q.readConcern != nil {
+		o.SetReadConcern(q.readConcern)
+	}
+	if q.writeConcern != nil {
+		o.SetWriteConcern(q.writeConcern)
+	}
+	c, err := q.store.Collection.Clone(o)
+	if err != nil {
+		return nil, err
+	}
+	return &mgm.Collection{Collection: c}, nil
+}
+
+func (q *QueryBuilder[T]) countOptions() *options.CountOptions {
+	o := options.Count()
+	if q.collation != nil {
+		o.SetCollation(q.collation)
+	}
+	if q.hint != nil {
+		o.SetHint(q.hint)
+	}
+	if q.maxTime > 0 {
+		o.SetMaxTime(q.maxTime)
+	}
+	if q.comment != "" {
+		o.SetComment(q.comment)
+	}
+	return o
+}
+
+func (q *QueryBuilder[T]) deleteOptions() *options.DeleteOptions {
+	o := options.Delete()
+	if q.collation != nil {
+		o.SetCollation(q.collation)
+	}
+	if q.hint != nil {
+		o.SetHint(q.hint)
+	}
+	if q.comment != "" {
+		o.SetComment(q.comment)
+	}
+	return o
+}
@@ -0,0 +1,91 @@
+package grimoire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
+)
+
+func TestQueryBuilder_DriverOptions(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().Where("status", "done").
+		ReadPreference(readpref.SecondaryPreferred()).
+		ReadConcern(readconcern.Majority()).
+		WriteConcern(writeconcern.Majority()).
+		Hint("status_1").
+		Collation(&options.Collation{Locale: "fr"}).
+		MaxTime(5 * time.Second).
+		AllowDiskUse().
+		BatchSize(500).
+		Comment("report").
+		NoCursorTimeout()
+
+	find := q.options()
+	assert.Equal(t, "status_1", find.Hint)
+	assert.Equal(t, 5*time.Second, *find.MaxTime)
+	assert.True(t, *find.AllowDiskUse)
+	assert.Equal(t, int32(500), *find.BatchSize)
+	assert.Equal(t, "report", *find.Comment)
+	assert.True(t, *find.NoCursorTimeout)
+	assert.Equal(t, "fr", find.Collation.Locale)
+
+	count := q.countOptions()
+	assert.Equal(t, "status_1", count.Hint)
+	assert.Equal(t, 5*time.Second, *count.MaxTime)
+	assert.Equal(t, "report", *count.Comment)
+	assert.Equal(t, "fr", count.Collation.Locale)
+
+	del := q.deleteOptions()
+	assert.Equal(t, "status_1", del.Hint)
+	assert.Equal(t, "report", del.Comment)
+	assert.Equal(t, "fr", del.Collation.Locale)
+
+	coll, err := q.collection()
+	assert.NoError(t, err)
+	assert.NotSame(t, s.Collection, coll)
+
+	coll, err = s.Query().collection()
+	assert.NoError(t, err)
+	assert.Same(t, s.Collection, coll)
+
+	assert.Equal(t, `db.downloads.find({"$and": [{"status": {"$eq": "done"}}]}).collation({"locale": "fr"}).limit(25)`+
+		`.hint("status_1").maxTimeMS(5000).allowDiskUse().batchSize(500).noCursorTimeout().comment("report")`+
+		`.readPref("secondaryPreferred").readConcern("majority")`, q.Shell())
+
+	before := s.Collection
+	assert.NoError(t, s.SetReadPreference(readpref.Nearest()))
+	assert.NoError(t, s.SetWriteConcern(writeconcern.W1()))
+	assert.NotSame(t, before, s.Collection)
+	assert.Equal(t, "downloads", s.Collection.Name())
+
+	// copies
+	s.RegisterScope("done", func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.Where("status", "done")
+	})
+	assert.NoError(t, s.SetDefaultScopes("done"))
+	before = s.Collection
+	secondary, err := s.WithReadPreference(readpref.Secondary())
+	assert.NoError(t, err)
+	assert.Same(t, before, s.Collection, "unchanged")
+	assert.NotSame(t, s.Collection, secondary.Collection)
+	assert.Equal(t, s.Query().Shell(), secondary.Query().Shell(), "default scopes")
+
+	secondary.RegisterScope("queued", func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.Where("status", "queued")
+	})
+	assert.NoError(t, secondary.SetDefaultScopes("queued"))
+	assert.ErrorIs(t, s.SetDefaultScopes("queued"), ErrUnknownScope, "own scopes")
+
+	_, err = s.WithReadConcern(readconcern.Majority())
+	assert.NoError(t, err)
+	_, err = s.WithWriteConcern(writeconcern.Majority())
+	assert.NoError(t, err)
+}
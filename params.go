@@ -0,0 +1,233 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+)
+
+var (
+	// ErrUnknownField is returned when a parameter refers to a field that is not allowed.
+	ErrUnknownField = errors.New("unknown field")
+	// ErrUnknownOperator is returned when a parameter uses an unsupported operator.
+	ErrUnknownOperator = errors.New("unknown operator")
+	// ErrInvalidValue is returned when a parameter value cannot be converted to the field type.
+	ErrInvalidValue = errors.New("invalid value")
+	// ErrInvalidOperator is returned when a parameter uses an operator that does not apply to
+	// the type of its field, such as like on a number.
+	ErrInvalidOperator = errors.New("invalid operator for field")
+)
+
+// maxPatternLength is the maximum length of the values of the like, prefix, suffix and contains
+// parameters, which are run as regular expressions.
+const maxPatternLength = 256
+
+// ParamError describes a query parameter that could not be parsed.
+type ParamError struct {
+	Param string
+	Value string
+	Err   error
+}
+
+func (e *ParamError) Error() string {
+	return fmt.Sprintf("grimoire: parameter %s=%q: %s", e.Param, e.Value, e.Err)
+}
+
+func (e *ParamError) Unwrap() error {
+	return e.Err
+}
+
+// ParseQuery builds a query from url.Values, such as the query string of an HTTP request.
+// Fields are restricted to the BSON fields of T, or to fields if any are given.
+//
+// The keys sort, limit and skip are reserved: sort is a comma separated list of fields,
+// prefixed with '-' for descending order. Any other key is a field, optionally followed by an
+// operator in brackets: eq (default), ne, gt, gte, lt, lte, in, nin, exists, like, prefix,
+// suffix and contains. The values of in and nin are comma separated. like, prefix, suffix and
+// contains only apply to string fields, and their values are at most 256 bytes long.
+//
+// Example:
+//
+//	?status=searching&size[gt]=10&tags[in]=a,b&sort=-created_at&limit=50
+func (s *Store[T]) ParseQuery(values url.Values, fields ...string) (*QueryBuilder[T], error) {
+	p, err := newParamParser[T](fields)
+	if err != nil {
+		return nil, err
+	}
+
+	q := s.Query()
+
+	// iterate in a stable order, so that the query is the same for the same values
+	keys := make([]string, 0, len(values))
+	for k := range values {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		for _, value := range values[key] {
+			if err := p.apply(q, key, value); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return q, nil
+}
+
+type paramParser[T mgm.Model] struct {
+	fields map[string]modelField
+}
+
+func newParamParser[T mgm.Model](allowed []string) (*paramParser[T], error) {
+	all := modelFields[T]()
+	if len(allowed) == 0 {
+		return &paramParser[T]{fields: all}, nil
+	}
+
+	fields := map[string]modelField{}
+	for _, name := range allowed {
+		f, ok := all[name]
+		if !ok {
+			return nil, fmt.Errorf("grimoire: allowed field %q: %w", name, ErrUnknownField)
+		}
+		fields[name] = f
+	}
+	return &paramParser[T]{fields: fields}, nil
+}
+
+func (p *paramParser[T]) apply(q *QueryBuilder[T], key, value string) error {
+	switch key {
+	case "sort":
+		return p.sort(q, value)
+	case "limit":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 1 {
+			return &ParamError{Param: key, Value: value, Err: fmt.Errorf("%w: must be a positive integer", ErrInvalidValue)}
+		}
+		q.Limit(n)
+		return nil
+	case "skip":
+		n, err := strconv.Atoi(value)
+		if err != nil || n < 0 {
+			return &ParamError{Param: key, Value: value, Err: fmt.Errorf("%w: must be a non-negative integer", ErrInvalidValue)}
+		}
+		q.Skip(n)
+		return nil
+	}
+
+	name, op := key, "eq"
+	if i := strings.IndexByte(key, '['); i > 0 && strings.HasSuffix(key, "]") {
+		name, op = key[:i], key[i+1:len(key)-1]
+	}
+
+	field, ok := p.fields[name]
+	if !ok {
+		return &ParamError{Param: key, Value: value, Err: ErrUnknownField}
+	}
+
+	invalid := func(err error) error {
+		return &ParamError{Param: key, Value: value, Err: fmt.Errorf("%w: %s", ErrInvalidValue, err)}
+	}
+
+	switch op {
+	case "like", "prefix", "suffix", "contains":
+		if !stringField(field.Type) {
+			return &ParamError{Param: key, Value: value, Err: ErrInvalidOperator}
+		}
+		if len(value) > maxPatternLength {
+			return &ParamError{Param: key, Value: value, Err: fmt.Errorf("%w: longer than %d bytes", ErrInvalidValue, maxPatternLength)}
+		}
+	}
+
+	switch op {
+	case "exists":
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return invalid(err)
+		}
+		if b {
+			q.Exists(name)
+		} else {
+			q.NotExists(name)
+		}
+		return nil
+	case "like":
+		q.Like(name, value)
+		return nil
+	case "prefix":
+		q.StartsWith(name, value)
+		return nil
+	case "suffix":
+		q.EndsWith(name, value)
+		return nil
+	case "contains":
+		q.Contains(name, value)
+		return nil
+	case "in", "nin":
+		list := []interface{}{}
+		for _, s := range strings.Split(value, ",") {
+			v, err := coerce(field.Type, s)
+			if err != nil {
+				return invalid(err)
+			}
+			list = append(list, v)
+		}
+		if op == "in" {
+			q.In(name, list)
+		} else {
+			q.NotIn(name, list)
+		}
+		return nil
+	}
+
+	add, ok := map[string]func(string, interface{}) *QueryBuilder[T]{
+		"eq":  q.Where,
+		"ne":  q.NotEqual,
+		"gt":  q.GreaterThan,
+		"gte": q.GreaterThanEqual,
+		"lt":  q.LessThan,
+		"lte": q.LessThanEqual,
+	}[op]
+	if !ok {
+		return &ParamError{Param: key, Value: value, Err: ErrUnknownOperator}
+	}
+
+	v, err := coerce(field.Type, value)
+	if err != nil {
+		return invalid(err)
+	}
+	add(name, v)
+	return nil
+}
+
+// stringField returns true for string fields, and arrays of strings, which the string matching
+// operators apply to.
+func stringField(t reflect.Type) bool {
+	if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+		t = indirect(t.Elem())
+	}
+	return t.Kind() == reflect.String
+}
+
+func (p *paramParser[T]) sort(q *QueryBuilder[T], value string) error {
+	for _, name := range strings.Split(value, ",") {
+		desc := strings.HasPrefix(name, "-")
+		name = strings.TrimPrefix(name, "-")
+		if _, ok := p.fields[name]; !ok {
+			return &ParamError{Param: "sort", Value: value, Err: fmt.Errorf("%w: %s", ErrUnknownField, name)}
+		}
+		if desc {
+			q.Desc(name)
+		} else {
+			q.Asc(name)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,97 @@
+package grimoire
+
+import (
+	"errors"
+	"math"
+	"net/url"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_ParseQuery(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	values, err := url.ParseQuery("status[in]=searching,loading&auto=true&timestamps.found[gte]=2024-01-01&thash[exists]=false&download_files.num[gt]=2&sort=-created_at,url&limit=50&skip=10")
+	assert.NoError(t, err)
+
+	q, err := s.ParseQuery(values)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{
+		{"auto": bson.M{"$eq": true}},
+		{"download_files.num": bson.M{"$gt": int64(2)}},
+		{"status": bson.M{"$in": []interface{}{"searching", "loading"}}},
+		{"thash": bson.M{"$exists": false}},
+		{"timestamps.found": bson.M{"$gte": time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)}},
+	}, q.values)
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}, {Key: "url", Value: 1}}, q.sort)
+	assert.Equal(t, int64(50), q.limit)
+	assert.Equal(t, int64(10), q.skip)
+
+	id := primitive.NewObjectID()
+	q, err = s.ParseQuery(url.Values{"medium_id": {id.Hex()}})
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"medium_id": bson.M{"$eq": id}}}, q.values)
+
+	// repeated wildcards are collapsed
+	q, err = s.ParseQuery(url.Values{"url[like]": {"http%%%.mkv"}})
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{{"url": bson.M{"$regex": primitive.Regex{Pattern: `^http.*\.mkv$`}}}}, q.values)
+}
+
+func TestStore_ParseQueryErrors(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	testCases := []struct {
+		name   string
+		values url.Values
+		fields []string
+		err    error
+		param  string
+	}{
+		{"unknown field", url.Values{"nope": {"1"}}, nil, ErrUnknownField, "nope"},
+		{"not allowed", url.Values{"thash": {"abc"}}, []string{"status"}, ErrUnknownField, "thash"},
+		{"unknown operator", url.Values{"status[regex]": {"a"}}, nil, ErrUnknownOperator, "status[regex]"},
+		{"bad bool", url.Values{"auto": {"maybe"}}, nil, ErrInvalidValue, "auto"},
+		{"bad id", url.Values{"medium_id[in]": {"a,b"}}, nil, ErrInvalidValue, "medium_id[in]"},
+		{"bad limit", url.Values{"limit": {"-1"}}, nil, ErrInvalidValue, "limit"},
+		{"bad sort", url.Values{"sort": {"-nope"}}, nil, ErrUnknownField, "sort"},
+		{"like number", url.Values{"download_files.num[like]": {"1%"}}, nil, ErrInvalidOperator, "download_files.num[like]"},
+		{"contains id", url.Values{"medium_id[contains]": {"abc"}}, nil, ErrInvalidOperator, "medium_id[contains]"},
+		{"long pattern", url.Values{"url[contains]": {strings.Repeat("a", 257)}}, nil, ErrInvalidValue, "url[contains]"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := s.ParseQuery(tc.values, tc.fields...)
+			assert.ErrorIs(t, err, tc.err)
+			var perr *ParamError
+			if assert.True(t, errors.As(err, &perr)) {
+				assert.Equal(t, tc.param, perr.Param)
+			}
+		})
+	}
+
+	_, err = s.ParseQuery(url.Values{}, "nope")
+	assert.ErrorIs(t, err, ErrUnknownField)
+}
+
+func TestCoerce_Unsigned(t *testing.T) {
+	v, err := coerce(reflect.TypeOf(uint64(0)), "9223372036854775807")
+	assert.NoError(t, err)
+	assert.Equal(t, int64(math.MaxInt64), v)
+
+	_, err = coerce(reflect.TypeOf(uint64(0)), "9223372036854775808")
+	assert.ErrorIs(t, err, ErrInvalidValue, "wraps to a negative int64")
+
+	_, err = coerce(reflect.TypeOf(uint8(0)), "256")
+	assert.Error(t, err)
+}
@@ -3,59 +3,198 @@ package grimoire
 import (
 	"context"
 	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/kamva/mgm/v3"
 	"github.com/kamva/mgm/v3/operator"
 	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
 	"go.mongodb.org/mongo-driver/mongo/options"
+	"go.mongodb.org/mongo-driver/mongo/readconcern"
+	"go.mongodb.org/mongo-driver/mongo/readpref"
+	"go.mongodb.org/mongo-driver/mongo/writeconcern"
 )
 
 type QueryBuilder[T mgm.Model] struct {
-	store  *Store[T]
-	values []bson.M
-	limit  int64
-	skip   int64
-	sort   bson.D
+	store      *Store[T]
+	values     []bson.M
+	limit      int64
+	skip       int64
+	sort       bson.D
+	projection bson.D
+	collation  *options.Collation
+	ctx        context.Context
+
+	readPref        *readpref.ReadPref
+	readConcern     *readconcern.ReadConcern
+	writeConcern    *writeconcern.WriteConcern
+	hint            interface{}
+	maxTime         time.Duration
+	allowDiskUse    bool
+	batchSize       int32
+	comment         string
+	noCursorTimeout bool
+
+	immutable bool
+	unscoped  bool
+	without   []string
+	preloads  []string
+	err       error
 }
 
+// String returns the query as a mongosh command, see Shell.
 func (q *QueryBuilder[T]) String() string {
-	return fmt.Sprintf("QueryBuilder[T] %#v", q.values)
+	return q.Shell()
+}
+
+// filter combines the clauses of the default scopes and the query builder values into a single
+// filter document.
+func (q *QueryBuilder[T]) filter() bson.M {
+	return filterOf(q.clauses())
+}
+
+func filterOf(values []bson.M) bson.M {
+	filter := bson.M{}
+	if len(values) > 0 {
+		filter["$and"] = values
+	}
+	return filter
+}
+
+// before is called before the query is executed as op (find, count or delete). It records the
+// shape of the query for the index advisor and runs the strict mode checks.
+func (q *QueryBuilder[T]) before(op string) error {
+	if q.err != nil {
+		return q.err
+	}
+	if _, err := q.store.tenant(q.context()); err != nil {
+		return err
+	}
+	if advisor := q.store.advisor.Load(); advisor != nil {
+		advisor.Record(q.Shape())
+	}
+
+	explain := q.Explain
+	switch op {
+	case "count":
+		explain = q.ExplainCount
+	case "delete":
+		explain = q.ExplainDeleteMany
+	}
+	return q.checkStrict(op, explain)
+}
+
+// WithContext sets the context used to execute the query. The context carries deadlines and
+// cancellation, and is the parent of the spans created when instrumentation is enabled.
+//
+// Example:
+//
+//	WithContext(r.Context())
+func (q *QueryBuilder[T]) WithContext(ctx context.Context) *QueryBuilder[T] {
+	q = q.mutable()
+	q.ctx = ctx
+	return q
+}
+
+func (q *QueryBuilder[T]) context() context.Context {
+	if q.ctx != nil {
+		return q.ctx
+	}
+	return mgm.Ctx()
+}
+
+// operation describes the operation name of the query with filter. Finds include the full
+// query shape for the slow query log.
+func (q *QueryBuilder[T]) operation(name string, filter interface{}) operation {
+	op := operation{name: name, filter: filter}
+	if name == "find" || name == "batch" {
+		op.query = func() string { return q.shell(filterShape(filter)) }
+	}
+	return op
 }
 
 // Run executes the query and returns a list of objects.
 func (q *QueryBuilder[T]) Run() ([]T, error) {
-	result := make([]T, 0)
-	filter := bson.M{}
-	if len(q.values) > 0 {
-		filter["$and"] = q.values
+	var result []T
+	err := q.store.observe(q.context(), q.operation("find", q.filter()), func(ctx context.Context) (int64, error) {
+		if err := q.before("find"); err != nil {
+			return 0, err
+		}
+		list, err := q.find(ctx, q.filter())
+		result = list
+		return int64(len(list)), err
+	})
+	if err != nil {
+		return nil, err
 	}
-	err := q.store.Collection.SimpleFind(&result, filter, q.options())
+	return result, nil
+}
+
+func (q *QueryBuilder[T]) find(ctx context.Context, filter interface{}) ([]T, error) {
+	coll, err := q.collection()
 	if err != nil {
 		return nil, err
 	}
 
+	result := make([]T, 0)
+	err = coll.SimpleFindWithCtx(ctx, &result, filter, q.options())
+	if err != nil {
+		return nil, err
+	}
+	if err := q.preload(ctx, result); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
+// batchTimeout bounds the count and each page of Batch, but not the callbacks.
+const batchTimeout = 120 * time.Second
+
 // Batch executes the query and yields 'size' objects at a time.
+// NOTE: the count and each page have their own timeout, so slow callbacks do not fail the batch.
 func (q *QueryBuilder[T]) Batch(size int64, f func(results []T) error) error {
-	filter := bson.M{}
-	if len(q.values) > 0 {
-		filter["$and"] = q.values
+	filter := q.filter()
+
+	parent := q.ctx
+	if parent == nil {
+		parent = context.Background()
+	}
+	if err := q.before("find"); err != nil {
+		return err
 	}
 
-	ctx, timeout := context.WithTimeout(context.Background(), 120*time.Second)
-	defer timeout()
+	var total int64
+	err := q.store.observe(parent, q.operation("count", filter), func(ctx context.Context) (int64, error) {
+		ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+		defer cancel()
 
-	total, err := q.CountWithContext(ctx)
+		var err error
+		total, err = q.count(ctx)
+		return total, err
+	})
 	if err != nil {
 		return err
 	}
+
+	page := func(skip, limit int64) ([]T, error) {
+		var list []T
+		err := q.store.observe(parent, q.operation("batch", filter), func(ctx context.Context) (int64, error) {
+			ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+			defer cancel()
+
+			var err error
+			list, err = q.Clone().Skip(int(skip)).Limit(int(limit)).find(ctx, filter)
+			return int64(len(list)), err
+		})
+		return list, err
+	}
+
 	if total <= size {
-		q.Skip(0)
-		q.Limit(int(size))
-		list, err := q.Run()
+		list, err := page(0, size)
 		if err != nil {
 			return err
 		}
@@ -63,15 +202,11 @@ func (q *QueryBuilder[T]) Batch(size int64, f func(results []T) error) error {
 	}
 
 	for i := int64(0); i < total; i += size {
-		result := make([]T, 0)
-		q.Skip(int(i))
-		q.Limit(int(size))
-		err := q.store.Collection.SimpleFind(&result, filter, q.options())
+		result, err := page(i, size)
 		if err != nil {
 			return err
 		}
-		err = f(result)
-		if err != nil {
+		if err := f(result); err != nil {
 			return err
 		}
 	}
@@ -94,7 +229,7 @@ func (q *QueryBuilder[T]) Each(batchSize int64, f func(result T) error) error {
 // First executes the query and returns the first object.
 func (q *QueryBuilder[T]) First() (T, error) {
 	var zero T
-	list, err := q.Limit(1).Run()
+	list, err := q.Clone().Limit(1).Run()
 	if err != nil {
 		return zero, err
 	}
@@ -105,45 +240,92 @@ func (q *QueryBuilder[T]) First() (T, error) {
 }
 
 // Raw executes the raw bson.M query and returns a list of objects.
-// NOTE: This does not use the query builder values.
+// NOTE: This does not use the query builder values, only the default scopes.
 func (q *QueryBuilder[T]) Raw(query bson.M) ([]T, error) {
-	result := make([]T, 0)
-	err := q.store.Collection.SimpleFind(&result, query, q.options())
+	if q.err != nil {
+		return nil, q.err
+	}
+	if !q.unscoped {
+		query = q.store.scopedFilter(query, q.without)
+	}
+	query, err := q.store.tenantFilter(q.context(), query)
 	if err != nil {
 		return nil, err
 	}
 
+	var result []T
+	err = q.store.observe(q.context(), q.operation("find", query), func(ctx context.Context) (int64, error) {
+		list, err := q.find(ctx, query)
+		result = list
+		return int64(len(list)), err
+	})
+	if err != nil {
+		return nil, err
+	}
 	return result, nil
 }
 
 // Count executes the query and returns the number of objects.
 func (q *QueryBuilder[T]) Count() (int64, error) {
-	return q.CountWithContext(mgm.Ctx())
+	return q.CountWithContext(q.context())
 }
 
 // CountWithContext executes the query and returns the number of objects.
 func (q *QueryBuilder[T]) CountWithContext(ctx context.Context) (int64, error) {
-	filter := bson.M{}
-	if len(q.values) > 0 {
-		filter["$and"] = q.values
+	q = q.clone()
+	q.ctx = ctx
+	var total int64
+	err := q.store.observe(ctx, q.operation("count", q.filter()), func(ctx context.Context) (int64, error) {
+		if err := q.before("count"); err != nil {
+			return 0, err
+		}
+		n, err := q.count(ctx)
+		total = n
+		return n, err
+	})
+	return total, err
+}
+
+func (q *QueryBuilder[T]) count(ctx context.Context) (int64, error) {
+	coll, err := q.collection()
+	if err != nil {
+		return 0, err
 	}
-	return q.store.Collection.CountDocuments(ctx, filter)
+	return coll.CountDocuments(ctx, q.filter(), q.countOptions())
 }
 
 // DeleteMany executes the query and deletes the objects.
 func (q *QueryBuilder[T]) DeleteMany() (int64, error) {
-	filter := bson.M{}
-	if len(q.values) > 0 {
-		filter["$and"] = q.values
-	}
-	n, err := q.store.Collection.DeleteMany(mgm.Ctx(), filter)
+	filter := q.filter()
+	var deleted int64
+	err := q.store.observe(q.context(), q.operation("delete", filter), func(ctx context.Context) (int64, error) {
+		if err := q.before("delete"); err != nil {
+			return 0, err
+		}
+		coll, err := q.collection()
+		if err != nil {
+			return 0, err
+		}
+		if q.maxTime > 0 {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, q.maxTime)
+			defer cancel()
+		}
+		n, err := coll.DeleteMany(ctx, filter, q.deleteOptions())
+		if err != nil {
+			return 0, err
+		}
+		deleted = n.DeletedCount
+		return deleted, nil
+	})
 	if err != nil {
 		return 0, err
 	}
-	return n.DeletedCount, nil
+	return deleted, nil
 }
 
 func (q *QueryBuilder[T]) addSort(field string, value int) *QueryBuilder[T] {
+	q = q.mutable()
 	q.sort = append(q.sort, bson.E{Key: field, Value: value})
 	return q
 }
@@ -176,6 +358,7 @@ func (q *QueryBuilder[T]) Desc(field string) *QueryBuilder[T] {
 //
 //	Limit(10)
 func (q *QueryBuilder[T]) Limit(limit int) *QueryBuilder[T] {
+	q = q.mutable()
 	q.limit = int64(limit)
 	return q
 }
@@ -185,10 +368,40 @@ func (q *QueryBuilder[T]) Limit(limit int) *QueryBuilder[T] {
 //
 //	Skip(10)
 func (q *QueryBuilder[T]) Skip(skip int) *QueryBuilder[T] {
+	q = q.mutable()
 	q.skip = int64(skip)
 	return q
 }
 
+// Select sets the fields returned by the query. Fields that are not selected are left at their
+// zero value in the results. The _id field is always returned unless excluded.
+// NOTE: fields should be valid BSON fields.
+//
+// Examples:
+//
+//	Select("title", "release_date")
+func (q *QueryBuilder[T]) Select(fields ...string) *QueryBuilder[T] {
+	q = q.mutable()
+	for _, f := range fields {
+		q.projection = append(q.projection, bson.E{Key: f, Value: 1})
+	}
+	return q
+}
+
+// Exclude sets fields that are not returned by the query.
+// NOTE: fields should be valid BSON fields.
+//
+// Examples:
+//
+//	Exclude("paths", "search_params")
+func (q *QueryBuilder[T]) Exclude(fields ...string) *QueryBuilder[T] {
+	q = q.mutable()
+	for _, f := range fields {
+		q.projection = append(q.projection, bson.E{Key: f, Value: 0})
+	}
+	return q
+}
+
 func (q *QueryBuilder[T]) options() *options.FindOptions {
 	o := &options.FindOptions{}
 	if q.limit > 0 {
@@ -196,6 +409,30 @@ func (q *QueryBuilder[T]) options() *options.FindOptions {
 	}
 	o.SetSkip(q.skip)
 	o.SetSort(q.sort)
+	if len(q.projection) > 0 {
+		o.SetProjection(q.projection)
+	}
+	if q.collation != nil {
+		o.SetCollation(q.collation)
+	}
+	if q.hint != nil {
+		o.SetHint(q.hint)
+	}
+	if q.maxTime > 0 {
+		o.SetMaxTime(q.maxTime)
+	}
+	if q.allowDiskUse {
+		o.SetAllowDiskUse(true)
+	}
+	if q.batchSize > 0 {
+		o.SetBatchSize(q.batchSize)
+	}
+	if q.comment != "" {
+		o.SetComment(q.comment)
+	}
+	if q.noCursorTimeout {
+		o.SetNoCursorTimeout(true)
+	}
 	return o
 }
 
@@ -206,8 +443,7 @@ func (q *QueryBuilder[T]) options() *options.FindOptions {
 //
 //	Where("name", "value")
 func (q *QueryBuilder[T]) Where(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Eq: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Eq: value}})
 }
 
 // In adds an in clause to the query.
@@ -217,8 +453,7 @@ func (q *QueryBuilder[T]) Where(field string, value interface{}) *QueryBuilder[T
 //
 //	In("name", []string{"foo", "bar"})
 func (q *QueryBuilder[T]) In(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.In: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.In: value}})
 }
 
 // NotIn adds a not in clause to the query.
@@ -228,8 +463,7 @@ func (q *QueryBuilder[T]) In(field string, value interface{}) *QueryBuilder[T] {
 //
 //	NotIn("name", []string{"foo", "bar"})
 func (q *QueryBuilder[T]) NotIn(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Nin: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Nin: value}})
 }
 
 // NotEqual adds a not equal clause to the query.
@@ -239,8 +473,7 @@ func (q *QueryBuilder[T]) NotIn(field string, value interface{}) *QueryBuilder[T
 //
 //	NotEqual("name", "value")
 func (q *QueryBuilder[T]) NotEqual(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Ne: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Ne: value}})
 }
 
 // LessThan adds a less than clause to the query.
@@ -250,8 +483,7 @@ func (q *QueryBuilder[T]) NotEqual(field string, value interface{}) *QueryBuilde
 //
 //	LessThan("name", 10)
 func (q *QueryBuilder[T]) LessThan(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Lt: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Lt: value}})
 }
 
 // LessThanEqual adds a less than or equal clause to the query.
@@ -261,8 +493,7 @@ func (q *QueryBuilder[T]) LessThan(field string, value interface{}) *QueryBuilde
 //
 //	LessThanEqual("name", 10)
 func (q *QueryBuilder[T]) LessThanEqual(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Lte: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Lte: value}})
 }
 
 // GreaterThan adds a greater than clause to the query.
@@ -272,8 +503,7 @@ func (q *QueryBuilder[T]) LessThanEqual(field string, value interface{}) *QueryB
 //
 //	GreaterThan("name", 10)
 func (q *QueryBuilder[T]) GreaterThan(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Gt: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Gt: value}})
 }
 
 // GreaterThanEqual adds a greater than or equal clause to the query.
@@ -283,8 +513,7 @@ func (q *QueryBuilder[T]) GreaterThan(field string, value interface{}) *QueryBui
 //
 //	GreaterThanEqual("name", 10)
 func (q *QueryBuilder[T]) GreaterThanEqual(field string, value interface{}) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Gte: value}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Gte: value}})
 }
 
 // Exists adds an exists clause to the query to check if a field exists.
@@ -294,8 +523,7 @@ func (q *QueryBuilder[T]) GreaterThanEqual(field string, value interface{}) *Que
 //
 //	Exists("name")
 func (q *QueryBuilder[T]) Exists(field string) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Exists: true}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Exists: true}})
 }
 
 // NotExists adds an exists clause to the query to check if a field does not exist.
@@ -305,34 +533,265 @@ func (q *QueryBuilder[T]) Exists(field string) *QueryBuilder[T] {
 //
 //	NotExists("name")
 func (q *QueryBuilder[T]) NotExists(field string) *QueryBuilder[T] {
-	q.values = append(q.values, bson.M{field: bson.M{operator.Exists: false}})
-	return q
+	return q.add(bson.M{field: bson.M{operator.Exists: false}})
+}
+
+// Like adds a pattern match clause to the query, using SQL LIKE syntax where '%' matches any
+// sequence of characters and '_' matches a single character. All other characters are matched
+// literally. The match is case sensitive.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	Like("title", "The % Ruler")
+func (q *QueryBuilder[T]) Like(field string, pattern string) *QueryBuilder[T] {
+	return q.regex(field, likeToRegex(pattern))
+}
+
+// StartsWith adds a prefix match clause to the query. The prefix is escaped and anchored, which
+// allows the query to use an index on field.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	StartsWith("title", "The Great")
+func (q *QueryBuilder[T]) StartsWith(field string, prefix string) *QueryBuilder[T] {
+	return q.regex(field, "^"+regexp.QuoteMeta(prefix))
+}
+
+// EndsWith adds a suffix match clause to the query. The suffix is escaped.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	EndsWith("url", ".torrent")
+func (q *QueryBuilder[T]) EndsWith(field string, suffix string) *QueryBuilder[T] {
+	return q.regex(field, regexp.QuoteMeta(suffix)+"$")
+}
+
+// Contains adds a substring match clause to the query. The substring is escaped.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	Contains("title", "Ruler")
+func (q *QueryBuilder[T]) Contains(field string, substr string) *QueryBuilder[T] {
+	return q.regex(field, regexp.QuoteMeta(substr))
+}
+
+// Matches adds a regular expression clause to the query. The expression is passed to the
+// server as is, so it should only use syntax supported by both Go and MongoDB (PCRE).
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	Matches("title", regexp.MustCompile(`(?i)^the \w+ ruler$`))
+func (q *QueryBuilder[T]) Matches(field string, re *regexp.Regexp) *QueryBuilder[T] {
+	return q.regex(field, re.String())
+}
+
+// EqualFold adds a case-insensitive equality clause to the query. Rather than using a regex,
+// this sets a case-insensitive collation on the query, so that an index with the same
+// collation can be used. The collation applies to all string comparisons in the query.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	EqualFold("title", "the great ruler")
+func (q *QueryBuilder[T]) EqualFold(field string, value string) *QueryBuilder[T] {
+	q = q.mutable()
+	q.collation = &options.Collation{Locale: "en", Strength: 2}
+	return q.Where(field, value)
+}
+
+// sub returns an empty query builder on the same store, used to collect nested conditions.
+func (q *QueryBuilder[T]) sub() *QueryBuilder[T] {
+	return q.store.sub()
+}
+
+func (q *QueryBuilder[T]) regex(field, pattern string) *QueryBuilder[T] {
+	return q.add(bson.M{field: bson.M{operator.Regex: primitive.Regex{Pattern: pattern}}})
+}
+
+// likeToRegex converts a SQL LIKE pattern to an anchored regular expression.
+func likeToRegex(pattern string) string {
+	var b strings.Builder
+	b.WriteString("^")
+	for i, r := range pattern {
+		switch r {
+		case '%':
+			if i > 0 && pattern[i-1] == '%' {
+				continue // repeated wildcards match the same strings as one
+			}
+			b.WriteString(".*")
+		case '_':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(string(r)))
+		}
+	}
+	b.WriteString("$")
+	return b.String()
 }
 
-// Or adds an or clause to the query. This is used when the or clause compares different fields. If you need to
-// compare the same field, use the In or NotIn functions.
-// NOTE: f should be a function that accepts a querybuilder.
+// ElemMatch adds an element match clause to the query, matching documents where at least one
+// element of the array field satisfies all of the conditions added by f. The conditions in f
+// use field names relative to the array element.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	ElemMatch("download_files", func(q *QueryBuilder[T]) {
+//		q.Where("num", 1).Exists("medium_id")
+//	})
+func (q *QueryBuilder[T]) ElemMatch(field string, f func(q *QueryBuilder[T])) *QueryBuilder[T] {
+	qq := q.sub()
+	f(qq)
+	cond := bson.M{}
+	if len(qq.values) > 0 {
+		cond[operator.And] = qq.values
+	}
+	return q.add(bson.M{field: bson.M{operator.ElemMatch: cond}})
+}
+
+// All adds an all clause to the query, matching array fields that contain every one of the values.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	All("text", []string{"foo", "bar"})
+func (q *QueryBuilder[T]) All(field string, values interface{}) *QueryBuilder[T] {
+	return q.add(bson.M{field: bson.M{operator.All: values}})
+}
+
+// Size adds a size clause to the query, matching array fields with exactly size elements.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	Size("paths", 0)
+func (q *QueryBuilder[T]) Size(field string, size int) *QueryBuilder[T] {
+	return q.add(bson.M{field: bson.M{operator.Size: size}})
+}
+
+// ArrayIndex returns the field path for the element at index of the array field, optionally
+// followed by subfields of that element. The result can be used as the field of any clause.
+//
+// Examples:
+//
+//	ArrayIndex("text", 0)                  // "text.0"
+//	ArrayIndex("download_files", 0, "num") // "download_files.0.num"
+func ArrayIndex(field string, index int, subfields ...string) string {
+	parts := append([]string{field, strconv.Itoa(index)}, subfields...)
+	return strings.Join(parts, ".")
+}
+
+// Or adds an or clause to the query. Each branch is a function that adds clauses to its own
+// query builder, and the query matches when all of the clauses of any branch match. Branches
+// can use every QueryBuilder method, including Or, And, Nor and Not, so groups nest to any depth.
+//
+// A branch that adds no clauses matches every document, so the query is unchanged when any
+// branch is empty, or when there are no branches at all.
 //
 // Example:
 //
 //	Or(func(q *QueryBuilder[T]) {
-//		return q.Where("field1", "value").Where("field2", "value2")
+//		q.Where("_type", "Movie").Where("kind", "movies3d")
+//	}, func(q *QueryBuilder[T]) {
+//		q.Where("_type", "Series").Not(func(q *QueryBuilder[T]) {
+//			q.Where("kind", "donghua")
+//		})
 //	})
-func (q *QueryBuilder[T]) Or(f func(q *QueryBuilder[T])) *QueryBuilder[T] {
-	ss := &Store[T]{
-		Client:     q.store.Client,
-		Database:   q.store.Database,
-		Collection: q.store.Collection,
+func (q *QueryBuilder[T]) Or(branches ...func(q *QueryBuilder[T])) *QueryBuilder[T] {
+	list := q.branches(branches)
+	if len(list) == 0 {
+		return q
 	}
-	qq := ss.Query()
+	for _, v := range list {
+		if len(v) == 0 {
+			return q
+		}
+	}
+	return q.add(bson.M{operator.Or: list})
+}
+
+// OrClauses adds an or clause to the query, matching when any of the clauses added by f match.
+// This is how Or behaved before it accepted several branches. The query is unchanged when f
+// adds no clauses.
+// deprecated: use Or with a branch per alternative
+//
+// Example:
+//
+//	OrClauses(func(q *QueryBuilder[T]) {
+//		q.Where("field1", "value").Where("field2", "value2")
+//	})
+func (q *QueryBuilder[T]) OrClauses(f func(q *QueryBuilder[T])) *QueryBuilder[T] {
+	qq := q.sub()
 	f(qq)
-	q.values = append(q.values, bson.M{operator.Or: qq.values})
-	return q
+	if len(qq.values) == 0 {
+		return q
+	}
+	return q.add(bson.M{operator.Or: qq.values})
+}
+
+// And adds an and clause to the query, matching when all of the clauses of every branch match.
+// This is mostly useful inside Or, Nor and Not, to group clauses together. Empty branches match
+// every document, so they are left out.
+//
+// Example:
+//
+//	And(func(q *QueryBuilder[T]) {
+//		q.Where("_type", "Movie")
+//	}, func(q *QueryBuilder[T]) {
+//		q.Where("kind", "movies3d")
+//	})
+func (q *QueryBuilder[T]) And(branches ...func(q *QueryBuilder[T])) *QueryBuilder[T] {
+	list := make([]bson.M, 0, len(branches))
+	for _, v := range q.branches(branches) {
+		if len(v) > 0 {
+			list = append(list, v)
+		}
+	}
+	if len(list) == 0 {
+		return q
+	}
+	return q.add(bson.M{operator.And: list})
+}
+
+// Nor adds a nor clause to the query, matching when none of the branches match. A branch
+// matches when all of its clauses match, so an empty branch matches every document and the
+// query then matches nothing.
+//
+// Example:
+//
+//	Nor(func(q *QueryBuilder[T]) {
+//		q.Where("status", "done")
+//	}, func(q *QueryBuilder[T]) {
+//		q.Where("auto", true).Where("force", false)
+//	})
+func (q *QueryBuilder[T]) Nor(branches ...func(q *QueryBuilder[T])) *QueryBuilder[T] {
+	list := q.branches(branches)
+	if len(list) == 0 {
+		return q
+	}
+	return q.add(bson.M{operator.Nor: list})
+}
+
+// Not adds a not clause to the query, matching when the clauses added by f do not all match.
+// When f adds no clauses, the query matches nothing.
+//
+// Example:
+//
+//	Not(func(q *QueryBuilder[T]) {
+//		q.Where("status", "done").Exists("thash")
+//	})
+func (q *QueryBuilder[T]) Not(f func(q *QueryBuilder[T])) *QueryBuilder[T] {
+	return q.Nor(f)
 }
 
 // ComplexOr adds an or clause to the query using two separate query builders. This is used
 // when the or clause requires two queries that are structurally different.
-// NOTE: f should be a function that accepts two query builders.
+// deprecated: use Or with multiple branches
 //
 // Example:
 //
@@ -341,16 +800,37 @@ func (q *QueryBuilder[T]) Or(f func(q *QueryBuilder[T])) *QueryBuilder[T] {
 //		qr.Where("type", "value2")
 //	})
 func (q *QueryBuilder[T]) ComplexOr(f func(qq *QueryBuilder[T], qr *QueryBuilder[T])) *QueryBuilder[T] {
-	ss := &Store[T]{
-		Client:     q.store.Client,
-		Database:   q.store.Database,
-		Collection: q.store.Collection,
-	}
-	qq := ss.Query()
-	qr := ss.Query()
+	qq := q.sub()
+	qr := q.sub()
 	f(qq, qr)
-	q.values = append(q.values, bson.M{operator.Or: bson.A{bson.M{operator.And: qq.values}, bson.M{operator.And: qr.values}}})
-	return q
+	if len(qq.values) == 0 || len(qr.values) == 0 {
+		return q
+	}
+	return q.add(bson.M{operator.Or: []bson.M{group(qq.values), group(qr.values)}})
+}
+
+// branches runs each branch against its own query builder and returns one condition per branch.
+// A branch that adds no clauses becomes an empty condition, which matches every document.
+func (q *QueryBuilder[T]) branches(branches []func(q *QueryBuilder[T])) []bson.M {
+	list := make([]bson.M, 0, len(branches))
+	for _, f := range branches {
+		qq := q.sub()
+		f(qq)
+		list = append(list, group(qq.values))
+	}
+	return list
+}
+
+// group combines values into a single condition that matches when all of the values match.
+func group(values []bson.M) bson.M {
+	switch len(values) {
+	case 0:
+		return bson.M{}
+	case 1:
+		return values[0]
+	default:
+		return bson.M{operator.And: values}
+	}
 }
 
 // If adds a field and value to the query if the condition is true.
@@ -360,8 +840,21 @@ func (q *QueryBuilder[T]) ComplexOr(f func(qq *QueryBuilder[T], qr *QueryBuilder
 //
 //	If(true, "name", "value")
 func (q *QueryBuilder[T]) If(cond bool, field string, value interface{}) *QueryBuilder[T] {
-	if cond {
-		q.values = append(q.values, bson.M{field: value})
+	if !cond {
+		return q
 	}
-	return q
+	return q.add(bson.M{field: value})
+}
+
+// WhereRaw adds a raw filter document to the query, for filters built outside of the query
+// builder.
+//
+// Example:
+//
+//	WhereRaw(bson.M{"status": "done", "size": bson.M{"$gt": 1024}})
+func (q *QueryBuilder[T]) WhereRaw(filter bson.M) *QueryBuilder[T] {
+	if len(filter) == 0 {
+		return q
+	}
+	return q.add(filter)
 }
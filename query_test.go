@@ -2,10 +2,12 @@ package grimoire
 
 import (
 	"fmt"
+	"regexp"
 	"testing"
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
 )
 
@@ -28,6 +30,7 @@ type Download struct {
 	//CreatedAt time.Time          `json:"created_at" bson:"created_at"`
 	//UpdatedAt time.Time          `json:"updated_at" bson:"updated_at"`
 	MediumId   primitive.ObjectID `json:"medium_id" bson:"medium_id"`
+	Medium     *Medium            `json:"medium,omitempty" bson:"-" grimoire:"ref,medium_id"`
 	Auto       bool               `json:"auto" bson:"auto"`
 	Multi      bool               `json:"multi" bson:"multi"`
 	Force      bool               `json:"force" bson:"force"`
@@ -157,7 +160,9 @@ func TestStore_QueryOr(t *testing.T) {
 	assert.NotNil(t, s)
 
 	q := s.Query().Or(func(qq *QueryBuilder[*Medium]) {
-		qq.Where("_type", "Series").Where("_type", "Movie")
+		qq.Where("_type", "Series")
+	}, func(qq *QueryBuilder[*Medium]) {
+		qq.Where("_type", "Movie")
 	})
 	list, err := q.Asc("release_date").Run()
 	assert.NoError(t, err)
@@ -206,3 +211,155 @@ func TestStore_QueryLimit(t *testing.T) {
 	assert.NotNil(t, list)
 	assert.Greater(t, len(list), 25, "should be more than 25")
 }
+
+func TestQueryBuilder_StringMatching(t *testing.T) {
+	s, err := New[*Medium]("mongodb://localhost:27017", "seer_development", "media")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().
+		Like("title", "The %.Ruler_").
+		StartsWith("directory", "/media/(tv)").
+		EndsWith("display", "[1080p]").
+		Contains("search", "a+b").
+		Matches("slug", regexp.MustCompile(`^the-\w+$`))
+
+	patterns := []string{}
+	for _, v := range q.values {
+		for _, cond := range v {
+			patterns = append(patterns, cond.(bson.M)["$regex"].(primitive.Regex).Pattern)
+		}
+	}
+	assert.Equal(t, []string{`^The .*\.Ruler.$`, `^/media/\(tv\)`, `\[1080p\]$`, `a\+b`, `^the-\w+$`}, patterns)
+
+	like := regexp.MustCompile(patterns[0])
+	assert.True(t, like.MatchString("The Great.Ruler!"))
+	assert.False(t, like.MatchString("The Great Ruler!"))
+	assert.Nil(t, q.collation)
+
+	q.EqualFold("title", "the great ruler")
+	assert.NotNil(t, q.collation)
+	assert.Equal(t, 2, q.collation.Strength)
+	assert.Equal(t, q.collation, q.options().Collation)
+}
+
+func TestQueryBuilder_ArrayOperators(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().
+		ElemMatch("download_files", func(qq *QueryBuilder[*Download]) {
+			qq.Where("num", 1).Exists("medium_id")
+		}).
+		All("tags", []string{"foo", "bar"}).
+		Size("download_files", 2).
+		Where(ArrayIndex("download_files", 0, "num"), 1)
+
+	assert.Equal(t, []bson.M{
+		{"download_files": bson.M{"$elemMatch": bson.M{"$and": []bson.M{
+			{"num": bson.M{"$eq": 1}},
+			{"medium_id": bson.M{"$exists": true}},
+		}}}},
+		{"tags": bson.M{"$all": []string{"foo", "bar"}}},
+		{"download_files": bson.M{"$size": 2}},
+		{"download_files.0.num": bson.M{"$eq": 1}},
+	}, q.values)
+}
+
+func TestQueryBuilder_BooleanExpressions(t *testing.T) {
+	s, err := New[*Medium]("mongodb://localhost:27017", "seer_development", "media")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().
+		Or(func(q *QueryBuilder[*Medium]) {
+			q.Where("_type", "Movie").Where("kind", "movies3d")
+		}, func(q *QueryBuilder[*Medium]) {
+			q.Where("_type", "Series").Nor(func(q *QueryBuilder[*Medium]) {
+				q.Where("kind", "donghua")
+			}, func(q *QueryBuilder[*Medium]) {
+				q.And(func(q *QueryBuilder[*Medium]) {
+					q.Where("broken", true)
+				}, func(q *QueryBuilder[*Medium]) {
+					q.Exists("title")
+				})
+			})
+		}).
+		Not(func(q *QueryBuilder[*Medium]) {
+			q.Where("active", false)
+		})
+
+	assert.Equal(t, []bson.M{
+		{"$or": []bson.M{
+			{"$and": []bson.M{
+				{"_type": bson.M{"$eq": "Movie"}},
+				{"kind": bson.M{"$eq": "movies3d"}},
+			}},
+			{"$and": []bson.M{
+				{"_type": bson.M{"$eq": "Series"}},
+				{"$nor": []bson.M{
+					{"kind": bson.M{"$eq": "donghua"}},
+					{"$and": []bson.M{
+						{"broken": bson.M{"$eq": true}},
+						{"title": bson.M{"$exists": true}},
+					}},
+				}},
+			}},
+		}},
+		{"$nor": []bson.M{
+			{"active": bson.M{"$eq": false}},
+		}},
+	}, q.values)
+
+	// an empty branch matches everything, so Or is a no-op and Nor and Not match nothing
+	q = s.Query().Or(func(q *QueryBuilder[*Medium]) {
+		q.Where("_type", "Movie")
+	}, func(q *QueryBuilder[*Medium]) {}).
+		And(func(q *QueryBuilder[*Medium]) {}, func(q *QueryBuilder[*Medium]) {
+			q.Where("kind", "movies3d")
+		}).
+		Not(func(q *QueryBuilder[*Medium]) {}).
+		Nor(func(q *QueryBuilder[*Medium]) {
+			q.Where("_type", "Series")
+		}, func(q *QueryBuilder[*Medium]) {})
+	assert.Equal(t, []bson.M{
+		{"$and": []bson.M{
+			{"kind": bson.M{"$eq": "movies3d"}},
+		}},
+		{"$nor": []bson.M{{}}},
+		{"$nor": []bson.M{
+			{"_type": bson.M{"$eq": "Series"}},
+			{},
+		}},
+	}, q.values)
+
+	q = s.Query().ComplexOr(func(qq *QueryBuilder[*Medium], qr *QueryBuilder[*Medium]) {
+		qq.Where("_type", "Movie")
+	})
+	assert.Empty(t, q.values)
+
+	// a single branch matches when all of its clauses match
+	q = s.Query().Or(func(q *QueryBuilder[*Medium]) {
+		q.Where("_type", "Series").Where("kind", "donghua")
+	})
+	assert.Equal(t, []bson.M{
+		{"$or": []bson.M{
+			{"$and": []bson.M{
+				{"_type": bson.M{"$eq": "Series"}},
+				{"kind": bson.M{"$eq": "donghua"}},
+			}},
+		}},
+	}, q.values)
+
+	// OrClauses treats each clause as an alternative
+	q = s.Query().OrClauses(func(q *QueryBuilder[*Medium]) {
+		q.Where("_type", "Series").Where("_type", "Movie")
+	}).OrClauses(func(q *QueryBuilder[*Medium]) {})
+	assert.Equal(t, []bson.M{
+		{"$or": []bson.M{
+			{"_type": bson.M{"$eq": "Series"}},
+			{"_type": bson.M{"$eq": "Movie"}},
+		}},
+	}, q.values)
+}
@@ -0,0 +1,826 @@
+package grimoire
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// SyntaxError describes an error in a filter string, see ParseFilter.
+type SyntaxError struct {
+	// Pos is the byte offset in the filter string where the error was found.
+	Pos int
+	Msg string
+	// Err is the underlying error, such as ErrUnknownField or ErrInvalidValue, if any.
+	Err error
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("grimoire: %s at position %d", e.Msg, e.Pos)
+}
+
+func (e *SyntaxError) Unwrap() error {
+	return e.Err
+}
+
+// ParseFilter builds a query from a Lucene style filter string. Values are converted to the
+// type of the BSON field of T they are compared with.
+//
+// Terms are written as field:value and combined with AND, OR, NOT and parentheses. Terms next
+// to each other are combined with AND, and a leading '-' is the same as NOT. Values can be:
+//
+//	status:searching           equal
+//	title:"The Great Ruler"    equal, quoted
+//	size:>100                  greater than, also >=, < and <=
+//	size:[10 TO 100]           inclusive range, {10 TO 100} is exclusive, * is unbounded
+//	status:(searching OR done) in
+//	thash:*                    exists
+//	title:The*                 wildcard, * matches any characters and ? a single character
+//
+// Example:
+//
+//	ParseFilter("status:searching AND auto:true AND size:>100 AND NOT thash:*")
+func (s *Store[T]) ParseFilter(input string) (*QueryBuilder[T], error) {
+	toks, err := lexFilter(input)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &filterParser{toks: toks}
+	q := s.Query()
+	if p.peek().kind == tokEOF {
+		return q, nil
+	}
+
+	n, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %s", t)}
+	}
+
+	c := &filterCompiler[T]{fields: modelFields[T]()}
+	if err := c.compile(q, n); err != nil {
+		return nil, err
+	}
+	return q, nil
+}
+
+// FilterString returns the filter of the query as a string that can be parsed by ParseFilter.
+// An error is returned if the query uses clauses that have no filter string equivalent, such as
+// ElemMatch or Expr.
+func (q *QueryBuilder[T]) FilterString() (string, error) {
+	parts := make([]string, 0, len(q.values))
+	for _, v := range q.values {
+		s, err := formatCondition(v, false)
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	return strings.Join(parts, " AND "), nil
+}
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokWord
+	tokQuoted
+	tokLParen
+	tokRParen
+	tokLBracket
+	tokRBracket
+	tokLBrace
+	tokRBrace
+)
+
+type token struct {
+	kind tokenKind
+	text string // raw text, including escapes, or the unquoted text of a quoted string
+	pos  int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of filter"
+	case tokQuoted:
+		return strconv.Quote(t.text)
+	}
+	return fmt.Sprintf("%q", t.text)
+}
+
+func (t token) keyword(k string) bool {
+	return t.kind == tokWord && t.text == k
+}
+
+const filterSpecial = `()[]{}"`
+
+func lexFilter(input string) ([]token, error) {
+	toks := []token{}
+	i := 0
+	for i < len(input) {
+		c := input[i]
+		switch {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			i++
+		case c == '"':
+			start := i
+			var b strings.Builder
+			i++
+			for {
+				if i >= len(input) {
+					return nil, &SyntaxError{Pos: start, Msg: "unterminated quoted string"}
+				}
+				if input[i] == '\\' && i+1 < len(input) {
+					b.WriteByte(input[i+1])
+					i += 2
+					continue
+				}
+				if input[i] == '"' {
+					i++
+					break
+				}
+				b.WriteByte(input[i])
+				i++
+			}
+			toks = append(toks, token{kind: tokQuoted, text: b.String(), pos: start})
+		case strings.IndexByte(filterSpecial, c) >= 0:
+			kind := map[byte]tokenKind{'(': tokLParen, ')': tokRParen, '[': tokLBracket, ']': tokRBracket, '{': tokLBrace, '}': tokRBrace}[c]
+			toks = append(toks, token{kind: kind, text: string(c), pos: i})
+			i++
+		default:
+			start := i
+			for i < len(input) {
+				c := input[i]
+				if c == '\\' {
+					if i+1 >= len(input) {
+						return nil, &SyntaxError{Pos: i, Msg: "escape at end of filter"}
+					}
+					i += 2
+					continue
+				}
+				if c == ' ' || c == '\t' || c == '\n' || c == '\r' || strings.IndexByte(filterSpecial, c) >= 0 {
+					break
+				}
+				i++
+			}
+			toks = append(toks, token{kind: tokWord, text: input[start:i], pos: start})
+		}
+	}
+	return append(toks, token{kind: tokEOF, pos: len(input)}), nil
+}
+
+// filter string syntax tree
+
+type filterNode interface{}
+
+type filterAnd []filterNode
+
+type filterOr []filterNode
+
+type filterNot struct {
+	node filterNode
+}
+
+type filterTerm struct {
+	field  string
+	pos    int
+	op     string // eq, gt, gte, lt, lte, exists, in, wildcard
+	values []token
+}
+
+type filterParser struct {
+	toks []token
+	i    int
+}
+
+func (p *filterParser) peek() token {
+	return p.toks[p.i]
+}
+
+func (p *filterParser) next() token {
+	t := p.toks[p.i]
+	if t.kind != tokEOF {
+		p.i++
+	}
+	return t
+}
+
+func (p *filterParser) parseOr() (filterNode, error) {
+	n, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	or := filterOr{n}
+	for p.peek().keyword("OR") {
+		p.next()
+		n, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		or = append(or, n)
+	}
+	if len(or) == 1 {
+		return or[0], nil
+	}
+	return or, nil
+}
+
+func (p *filterParser) parseAnd() (filterNode, error) {
+	n, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	and := filterAnd{n}
+	for {
+		t := p.peek()
+		if t.keyword("AND") {
+			p.next()
+		} else if t.kind == tokEOF || t.kind == tokRParen || t.keyword("OR") {
+			break
+		}
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		and = append(and, n)
+	}
+	if len(and) == 1 {
+		return and[0], nil
+	}
+	return and, nil
+}
+
+func (p *filterParser) parseUnary() (filterNode, error) {
+	t := p.peek()
+	if t.keyword("NOT") {
+		p.next()
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{n}, nil
+	}
+	if t.kind == tokWord && strings.HasPrefix(t.text, "-") && len(t.text) > 1 {
+		p.toks[p.i].text = t.text[1:]
+		p.toks[p.i].pos++
+		n, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return filterNot{n}, nil
+	}
+	if t.kind == tokLParen {
+		p.next()
+		n, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if t := p.next(); t.kind != tokRParen {
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected ')', found %s", t)}
+		}
+		return n, nil
+	}
+	return p.parseTerm()
+}
+
+func (p *filterParser) parseTerm() (filterNode, error) {
+	t := p.next()
+	if t.kind != tokWord || t.keyword("AND") || t.keyword("OR") {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected field:value, found %s", t)}
+	}
+
+	colon := indexUnescaped(t.text, ':')
+	if colon <= 0 {
+		return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected field:value, found %s", t)}
+	}
+
+	term := &filterTerm{field: unescapeFilter(t.text[:colon]), pos: t.pos, op: "eq"}
+	rest := t.text[colon+1:]
+	restPos := t.pos + colon + 1
+
+	for _, op := range []struct{ prefix, op string }{{">=", "gte"}, {"<=", "lte"}, {">", "gt"}, {"<", "lt"}} {
+		if strings.HasPrefix(rest, op.prefix) {
+			term.op = op.op
+			rest = rest[len(op.prefix):]
+			restPos += len(op.prefix)
+			break
+		}
+	}
+
+	if rest != "" {
+		value := token{kind: tokWord, text: rest, pos: restPos}
+		if term.op == "eq" {
+			if rest == "*" {
+				term.op = "exists"
+			} else if hasWildcard(rest) {
+				term.op = "wildcard"
+			}
+		}
+		term.values = []token{value}
+		return term, nil
+	}
+
+	v := p.next()
+	switch {
+	case v.kind == tokQuoted:
+		term.values = []token{v}
+		return term, nil
+	case v.kind == tokLParen && term.op == "eq":
+		return p.parseList(term, v)
+	case (v.kind == tokLBracket || v.kind == tokLBrace) && term.op == "eq":
+		return p.parseRange(term, v)
+	}
+	return nil, &SyntaxError{Pos: v.pos, Msg: fmt.Sprintf("expected value, found %s", v)}
+}
+
+func (p *filterParser) parseList(term *filterTerm, open token) (filterNode, error) {
+	term.op = "in"
+	for {
+		t := p.next()
+		switch {
+		case t.kind == tokRParen && len(term.values) > 0:
+			return term, nil
+		case t.keyword("OR") && len(term.values) > 0:
+			continue
+		case t.kind == tokQuoted || (t.kind == tokWord && !t.keyword("AND") && !t.keyword("NOT") && !hasWildcard(t.text)):
+			term.values = append(term.values, t)
+		case t.kind == tokEOF:
+			return nil, &SyntaxError{Pos: open.pos, Msg: "unterminated list"}
+		default:
+			return nil, &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("expected value, found %s", t)}
+		}
+	}
+}
+
+func (p *filterParser) parseRange(term *filterTerm, open token) (filterNode, error) {
+	low := p.next()
+	to := p.next()
+	high := p.next()
+	end := p.next()
+
+	for _, v := range []token{low, high} {
+		if v.kind != tokWord && v.kind != tokQuoted {
+			return nil, &SyntaxError{Pos: v.pos, Msg: fmt.Sprintf("expected value, found %s", v)}
+		}
+	}
+	if !to.keyword("TO") {
+		return nil, &SyntaxError{Pos: to.pos, Msg: fmt.Sprintf("expected TO, found %s", to)}
+	}
+	closer := tokRBracket
+	lowOp, highOp := "gte", "lte"
+	if open.kind == tokLBrace {
+		closer = tokRBrace
+		lowOp, highOp = "gt", "lt"
+	}
+	if end.kind != closer {
+		return nil, &SyntaxError{Pos: end.pos, Msg: fmt.Sprintf("expected end of range, found %s", end)}
+	}
+
+	and := filterAnd{}
+	if !(low.kind == tokWord && low.text == "*") {
+		and = append(and, &filterTerm{field: term.field, pos: term.pos, op: lowOp, values: []token{low}})
+	}
+	if !(high.kind == tokWord && high.text == "*") {
+		and = append(and, &filterTerm{field: term.field, pos: term.pos, op: highOp, values: []token{high}})
+	}
+	if len(and) == 0 {
+		return &filterTerm{field: term.field, pos: term.pos, op: "exists"}, nil
+	}
+	return and, nil
+}
+
+type filterCompiler[T mgm.Model] struct {
+	fields map[string]modelField
+}
+
+func (c *filterCompiler[T]) compile(q *QueryBuilder[T], n filterNode) error {
+	switch n := n.(type) {
+	case filterAnd:
+		for _, child := range n {
+			if err := c.compile(q, child); err != nil {
+				return err
+			}
+		}
+	case filterOr:
+		var err error
+		branches := make([]func(q *QueryBuilder[T]), 0, len(n))
+		for _, child := range n {
+			child := child
+			branches = append(branches, func(q *QueryBuilder[T]) {
+				if err == nil {
+					err = c.compile(q, child)
+				}
+			})
+		}
+		q.Or(branches...)
+		return err
+	case filterNot:
+		var err error
+		q.Not(func(q *QueryBuilder[T]) {
+			err = c.compile(q, n.node)
+		})
+		return err
+	case *filterTerm:
+		return c.term(q, n)
+	}
+	return nil
+}
+
+func (c *filterCompiler[T]) term(q *QueryBuilder[T], t *filterTerm) error {
+	field, ok := c.fields[t.field]
+	if !ok {
+		return &SyntaxError{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", t.field), Err: ErrUnknownField}
+	}
+
+	switch t.op {
+	case "exists":
+		q.Exists(t.field)
+		return nil
+	case "wildcard":
+		c.wildcard(q, t.field, t.values[0].text)
+		return nil
+	}
+
+	values := make([]interface{}, 0, len(t.values))
+	for _, tok := range t.values {
+		text := tok.text
+		if tok.kind == tokWord {
+			text = unescapeFilter(text)
+		}
+		v, err := coerce(field.Type, text)
+		if err != nil {
+			return &SyntaxError{Pos: tok.pos, Msg: fmt.Sprintf("invalid value for %s: %s", t.field, err), Err: ErrInvalidValue}
+		}
+		values = append(values, v)
+	}
+
+	switch t.op {
+	case "in":
+		q.In(t.field, values)
+	case "gt":
+		q.GreaterThan(t.field, values[0])
+	case "gte":
+		q.GreaterThanEqual(t.field, values[0])
+	case "lt":
+		q.LessThan(t.field, values[0])
+	case "lte":
+		q.LessThanEqual(t.field, values[0])
+	default:
+		q.Where(t.field, values[0])
+	}
+	return nil
+}
+
+// wildcard adds a pattern clause for raw, using the prefix, suffix and substring operators when
+// possible so that prefixes can use an index.
+func (c *filterCompiler[T]) wildcard(q *QueryBuilder[T], field, raw string) {
+	leading := strings.HasPrefix(raw, "*")
+	trailing := len(raw) > 1 && raw[len(raw)-1] == '*' && !lastEscaped(raw)
+	inner := raw
+	if leading {
+		inner = inner[1:]
+	}
+	if trailing {
+		inner = inner[:len(inner)-1]
+	}
+	if inner != "" && !hasWildcard(inner) {
+		literal := unescapeFilter(inner)
+		switch {
+		case leading && trailing:
+			q.Contains(field, literal)
+		case trailing:
+			q.StartsWith(field, literal)
+		default:
+			q.EndsWith(field, literal)
+		}
+		return
+	}
+
+	var b strings.Builder
+	b.WriteString("^")
+	for i := 0; i < len(raw); i++ {
+		switch raw[i] {
+		case '\\':
+			i++
+			b.WriteString(regexp.QuoteMeta(raw[i : i+1]))
+		case '*':
+			b.WriteString(".*")
+		case '?':
+			b.WriteString(".")
+		default:
+			b.WriteString(regexp.QuoteMeta(raw[i : i+1]))
+		}
+	}
+	b.WriteString("$")
+	q.regex(field, b.String())
+}
+
+// indexUnescaped returns the index of the first c in raw that is not escaped, or -1.
+func indexUnescaped(raw string, c byte) int {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' {
+			i++
+			continue
+		}
+		if raw[i] == c {
+			return i
+		}
+	}
+	return -1
+}
+
+// lastEscaped returns true when the last character of raw is escaped.
+func lastEscaped(raw string) bool {
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' {
+			i++
+			if i == len(raw)-1 {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func hasWildcard(raw string) bool {
+	return indexUnescaped(raw, '*') >= 0 || indexUnescaped(raw, '?') >= 0
+}
+
+func unescapeFilter(raw string) string {
+	if !strings.Contains(raw, `\`) {
+		return raw
+	}
+	var b strings.Builder
+	for i := 0; i < len(raw); i++ {
+		if raw[i] == '\\' && i+1 < len(raw) {
+			i++
+		}
+		b.WriteByte(raw[i])
+	}
+	return b.String()
+}
+
+// formatting
+
+// formatCondition formats a single query builder value. When nested is true, conditions with
+// more than one term are wrapped in parentheses.
+func formatCondition(cond bson.M, nested bool) (string, error) {
+	keys := make([]string, 0, len(cond))
+	for k := range cond {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	parts := make([]string, 0, len(keys))
+	for _, k := range keys {
+		s, err := formatClause(k, cond[k])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	s := strings.Join(parts, " AND ")
+	if nested {
+		s = "(" + s + ")"
+	}
+	return s, nil
+}
+
+func formatClause(key string, value interface{}) (string, error) {
+	switch key {
+	case "$and", "$or", "$nor":
+		list, ok := conditionList(value)
+		if !ok || len(list) == 0 {
+			return "", fmt.Errorf("grimoire: filter string: invalid %s", key)
+		}
+		parts := make([]string, 0, len(list))
+		for _, c := range list {
+			s, err := formatCondition(c, true)
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, s)
+		}
+		sep := " AND "
+		if key != "$and" {
+			sep = " OR "
+		}
+		s := strings.Join(parts, sep)
+		if len(parts) > 1 {
+			s = "(" + s + ")"
+		}
+		if key == "$nor" {
+			s = "NOT " + s
+		}
+		return s, nil
+	}
+	if strings.HasPrefix(key, "$") {
+		return "", fmt.Errorf("grimoire: filter string: unsupported operator %s", key)
+	}
+
+	field := escapeFilter(key)
+	ops, ok := value.(bson.M)
+	if !ok {
+		v, err := formatValue(value)
+		if err != nil {
+			return "", err
+		}
+		return field + ":" + v, nil
+	}
+
+	opKeys := make([]string, 0, len(ops))
+	for k := range ops {
+		opKeys = append(opKeys, k)
+	}
+	sort.Strings(opKeys)
+
+	parts := make([]string, 0, len(ops))
+	for _, op := range opKeys {
+		s, err := formatOperator(field, op, ops[op])
+		if err != nil {
+			return "", err
+		}
+		parts = append(parts, s)
+	}
+	if len(parts) == 1 {
+		return parts[0], nil
+	}
+	return "(" + strings.Join(parts, " AND ") + ")", nil
+}
+
+func formatOperator(field, op string, value interface{}) (string, error) {
+	prefix := map[string]string{"$eq": "", "$ne": "", "$gt": ">", "$gte": ">=", "$lt": "<", "$lte": "<="}
+	switch op {
+	case "$eq", "$ne", "$gt", "$gte", "$lt", "$lte":
+		v, err := formatValue(value)
+		if err != nil {
+			return "", err
+		}
+		s := field + ":" + prefix[op] + v
+		if op == "$ne" {
+			s = "NOT " + s
+		}
+		return s, nil
+	case "$exists":
+		if b, ok := value.(bool); ok {
+			if b {
+				return field + ":*", nil
+			}
+			return "NOT " + field + ":*", nil
+		}
+	case "$in", "$nin":
+		rv := reflect.ValueOf(value)
+		if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array || rv.Len() == 0 {
+			break
+		}
+		parts := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			v, err := formatValue(rv.Index(i).Interface())
+			if err != nil {
+				return "", err
+			}
+			parts = append(parts, v)
+		}
+		s := field + ":(" + strings.Join(parts, " OR ") + ")"
+		if op == "$nin" {
+			s = "NOT " + s
+		}
+		return s, nil
+	case "$regex":
+		if re, ok := value.(primitive.Regex); ok && re.Options == "" {
+			w, err := regexToWildcard(re.Pattern)
+			if err != nil {
+				return "", err
+			}
+			return field + ":" + w, nil
+		}
+	}
+	return "", fmt.Errorf("grimoire: filter string: unsupported operator %s on %s", op, field)
+}
+
+func conditionList(value interface{}) ([]bson.M, bool) {
+	switch v := value.(type) {
+	case []bson.M:
+		return v, true
+	case bson.A:
+		list := make([]bson.M, 0, len(v))
+		for _, e := range v {
+			m, ok := e.(bson.M)
+			if !ok {
+				return nil, false
+			}
+			list = append(list, m)
+		}
+		return list, true
+	}
+	return nil, false
+}
+
+func formatValue(value interface{}) (string, error) {
+	switch v := value.(type) {
+	case string:
+		return formatString(v), nil
+	case primitive.Symbol:
+		return formatString(string(v)), nil
+	case bool:
+		return strconv.FormatBool(v), nil
+	case int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64:
+		return fmt.Sprintf("%d", v), nil
+	case float32:
+		return strconv.FormatFloat(float64(v), 'g', -1, 32), nil
+	case float64:
+		return strconv.FormatFloat(v, 'g', -1, 64), nil
+	case time.Time:
+		return strconv.Quote(v.Format(time.RFC3339Nano)), nil
+	case primitive.DateTime:
+		return strconv.Quote(v.Time().UTC().Format(time.RFC3339Nano)), nil
+	case primitive.ObjectID:
+		return v.Hex(), nil
+	}
+	return "", fmt.Errorf("grimoire: filter string: unsupported value %#v", value)
+}
+
+var bareValue = regexp.MustCompile(`^[A-Za-z0-9_.@/+][A-Za-z0-9_.@/+-]*$`)
+
+func formatString(s string) string {
+	if bareValue.MatchString(s) && s != "AND" && s != "OR" && s != "NOT" && s != "TO" {
+		return s
+	}
+	var b strings.Builder
+	b.WriteByte('"')
+	for i := 0; i < len(s); i++ {
+		if s[i] == '"' || s[i] == '\\' {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	b.WriteByte('"')
+	return b.String()
+}
+
+// escapeFilter escapes the characters of s that have a meaning in an unquoted filter string.
+func escapeFilter(s string) string {
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		if strings.IndexByte(" \t\r\n\\:*?<>=-"+filterSpecial, s[i]) >= 0 {
+			b.WriteByte('\\')
+		}
+		b.WriteByte(s[i])
+	}
+	return b.String()
+}
+
+// regexMeta lists the regular expression metacharacters, the only characters regexToWildcard
+// accepts escaped. Other escapes, like \d or \w, are character classes or assertions.
+const regexMeta = `\.+*?()|[]{}^$`
+
+// regexToWildcard converts a regular expression made of literals, '.' and '.*' to a wildcard
+// value, which is the form of the expressions created by the string matching operators.
+func regexToWildcard(pattern string) (string, error) {
+	var b strings.Builder
+	start, end := false, false
+	for i := 0; i < len(pattern); i++ {
+		c := pattern[i]
+		switch {
+		case c == '^' && i == 0:
+			start = true
+		case c == '$' && i == len(pattern)-1:
+			end = true
+		case c == '\\' && i+1 < len(pattern) && strings.IndexByte(regexMeta, pattern[i+1]) >= 0:
+			i++
+			b.WriteString(escapeFilter(pattern[i : i+1]))
+		case c == '.' && i+1 < len(pattern) && pattern[i+1] == '*':
+			i++
+			b.WriteByte('*')
+		case c == '.':
+			b.WriteByte('?')
+		case strings.IndexByte(regexMeta, c) >= 0:
+			return "", fmt.Errorf("grimoire: filter string: unsupported regular expression %q", pattern)
+		default:
+			b.WriteString(escapeFilter(pattern[i : i+1]))
+		}
+	}
+	w := b.String()
+	if !start {
+		w = "*" + w
+	}
+	if !end {
+		w = w + "*"
+	}
+	return w, nil
+}
@@ -0,0 +1,145 @@
+package grimoire
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestStore_ParseFilter(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q, err := s.ParseFilter(`status:searching AND auto:true AND download_files.num:>100 AND NOT thash:*`)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{
+		{"status": bson.M{"$eq": "searching"}},
+		{"auto": bson.M{"$eq": true}},
+		{"download_files.num": bson.M{"$gt": int64(100)}},
+		{"$nor": []bson.M{{"thash": bson.M{"$exists": true}}}},
+	}, q.values)
+
+	q, err = s.ParseFilter(`(status:(searching OR loading) url:https*) OR -force:false download_files.num:[1 TO *] tdo_id:*abc?`)
+	assert.NoError(t, err)
+	assert.Equal(t, []bson.M{
+		{"$or": []bson.M{
+			{"$and": []bson.M{
+				{"status": bson.M{"$in": []interface{}{"searching", "loading"}}},
+				{"url": bson.M{"$regex": primitive.Regex{Pattern: "^https"}}},
+			}},
+			{"$and": []bson.M{
+				{"$nor": []bson.M{{"force": bson.M{"$eq": false}}}},
+				{"download_files.num": bson.M{"$gte": int64(1)}},
+				{"tdo_id": bson.M{"$regex": primitive.Regex{Pattern: "^.*abc.$"}}},
+			}},
+		}},
+	}, q.values)
+
+	q, err = s.ParseFilter("")
+	assert.NoError(t, err)
+	assert.Empty(t, q.values)
+}
+
+func TestStore_ParseFilterErrors(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	testCases := []struct {
+		input string
+		pos   int
+		err   error
+	}{
+		{`status:searching AND`, 20, nil},
+		{`status:"searching`, 7, nil},
+		{`(status:searching`, 17, nil},
+		{`status:searching nope:1`, 17, ErrUnknownField},
+		{`auto:true download_files.num:>abc`, 30, ErrInvalidValue},
+		{`searching`, 0, nil},
+		{`download_files.num:[1 TO 5}`, 26, nil},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			_, err := s.ParseFilter(tc.input)
+			var serr *SyntaxError
+			if assert.True(t, errors.As(err, &serr), "syntax error") {
+				assert.Equal(t, tc.pos, serr.Pos, serr.Error())
+			}
+			if tc.err != nil {
+				assert.ErrorIs(t, err, tc.err)
+			}
+		})
+	}
+}
+
+func TestQueryBuilder_FilterString(t *testing.T) {
+	s, err := New[*Medium]("mongodb://localhost:27017", "seer_development", "media")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	q := s.Query().
+		Where("_type", "Series").
+		In("kind", []string{"anime", "donghua"}).
+		GreaterThanEqual("search_params.resolution", 720).
+		StartsWith("title", "The Great").
+		NotEqual("source", "tvdb").
+		Or(func(q *QueryBuilder[*Medium]) {
+			q.Where("active", true)
+		}, func(q *QueryBuilder[*Medium]) {
+			q.NotExists("cover")
+		})
+
+	str, err := q.FilterString()
+	assert.NoError(t, err)
+	assert.Equal(t, `_type:Series AND kind:(anime OR donghua) AND search_params.resolution:>=720 AND title:The\ Great* AND NOT source:tvdb AND (active:true OR NOT cover:*)`, str)
+
+	parsed, err := s.ParseFilter(str)
+	assert.NoError(t, err)
+	again, err := parsed.FilterString()
+	assert.NoError(t, err)
+	assert.Equal(t, str, again)
+
+	_, err = s.Query().Size("paths", 1).FilterString()
+	assert.Error(t, err)
+
+	// escapes other than metacharacters are not literals
+	for _, re := range []string{`^A\d$`, `\w+`, `a\sb`, `\bword`, `a\nb`} {
+		_, err = s.Query().Matches("title", regexp.MustCompile(re)).FilterString()
+		assert.ErrorContains(t, err, "unsupported regular expression", re)
+	}
+	str, err = s.Query().Matches("title", regexp.MustCompile(`^A\.b\$`)).FilterString()
+	assert.NoError(t, err)
+	assert.Equal(t, `title:A.b$*`, str)
+
+	// escaped wildcards are literals
+	testCases := []struct {
+		input string
+		value bson.M
+	}{
+		{`title:*a\*`, bson.M{"title": bson.M{"$regex": primitive.Regex{Pattern: `a\*$`}}}},
+		{`title:a\*`, bson.M{"title": bson.M{"$eq": "a*"}}},
+		{`title:a\**`, bson.M{"title": bson.M{"$regex": primitive.Regex{Pattern: `^a\*`}}}},
+		{`title:*a\\*`, bson.M{"title": bson.M{"$regex": primitive.Regex{Pattern: `a\\`}}}},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.input, func(t *testing.T) {
+			q, err := s.ParseFilter(tc.input)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.Equal(t, []bson.M{tc.value}, q.values)
+
+			str, err := q.FilterString()
+			assert.NoError(t, err)
+			parsed, err := s.ParseFilter(str)
+			if assert.NoError(t, err, str) {
+				assert.Equal(t, q.values, parsed.values, str)
+			}
+		})
+	}
+}
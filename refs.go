@@ -0,0 +1,190 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// ErrUnknownRef is returned when a query preloads a reference that is not declared on the model
+// or not linked to a store with SetRef.
+var ErrUnknownRef = errors.New("unknown reference")
+
+// refBatchSize is the maximum number of ids in each query that loads references.
+const refBatchSize = 1000
+
+// RefStore is a store that referenced documents are loaded from, see SetRef. Every *Store[T]
+// is a RefStore.
+type RefStore interface {
+	refType() reflect.Type
+	findByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]reflect.Value, error)
+}
+
+// reference is a reference declared on a model and linked to a store.
+type reference struct {
+	// field is the index of the field holding the referenced documents.
+	field []int
+	// id is the index of the field holding the referenced ids.
+	id []int
+	// many is true when the field holds a slice of documents.
+	many  bool
+	store RefStore
+}
+
+// SetRef links the reference name of T to the store the referenced documents are loaded from.
+// The reference is declared with a grimoire tag on the field that holds the referenced document,
+// naming the field that holds its id. A primitive.ObjectID id loads a single document, and a
+// []primitive.ObjectID id loads a slice of documents. See Preload.
+//
+// Example:
+//
+//	type Download struct {
+//		grimoire.Document `bson:",inline"`
+//		MediumId primitive.ObjectID `bson:"medium_id"`
+//		Medium   *Medium            `bson:"-" grimoire:"ref,medium_id"`
+//	}
+//
+//	err := downloads.SetRef("Medium", media)
+func (s *Store[T]) SetRef(name string, store RefStore) error {
+	t := modelType[T]()
+	sf, ok := t.FieldByName(name)
+	if !ok {
+		return fmt.Errorf("grimoire: %w: %s", ErrUnknownRef, name)
+	}
+	tag, ok, err := parseTag(sf)
+	if err != nil {
+		return fmt.Errorf("grimoire: ref %s: %w", name, err)
+	}
+	if !ok || tag.Ref == "" {
+		return fmt.Errorf("grimoire: %w: %s has no ref tag", ErrUnknownRef, name)
+	}
+
+	id, ok := fieldIndexByBSONName(t, tag.Ref)
+	if !ok {
+		return fmt.Errorf("grimoire: ref %s: %w: %s", name, ErrUnknownField, tag.Ref)
+	}
+	idType := t.FieldByIndex(id).Type
+
+	ref := &reference{field: sf.Index, id: id, store: store}
+	switch {
+	case idType == objectIDType && sf.Type == store.refType():
+	case idType == reflect.SliceOf(objectIDType) && sf.Type == reflect.SliceOf(store.refType()):
+		ref.many = true
+	default:
+		return fmt.Errorf("grimoire: ref %s: cannot load %s from %s ids into %s", name, store.refType(), idType, sf.Type)
+	}
+
+	if s.refs == nil {
+		s.refs = map[string]*reference{}
+	}
+	s.refs[name] = ref
+	return nil
+}
+
+// Preload loads the named references of the results of the query, with one query on the
+// referenced store for each reference, see SetRef.
+//
+// Example:
+//
+//	list, err := s.Query().Where("status", "done").Preload("Medium").Run()
+func (q *QueryBuilder[T]) Preload(names ...string) *QueryBuilder[T] {
+	q = q.mutable()
+	q.preloads = append(q.preloads, names...)
+	return q
+}
+
+// preload loads the references of list requested by the query.
+func (q *QueryBuilder[T]) preload(ctx context.Context, list []T) error {
+	for _, name := range q.preloads {
+		ref, ok := q.store.refs[name]
+		if !ok {
+			return fmt.Errorf("grimoire: %w: %s", ErrUnknownRef, name)
+		}
+		if err := ref.load(ctx, list); err != nil {
+			return fmt.Errorf("grimoire: preload %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+func (r *reference) load(ctx context.Context, list interface{}) error {
+	docs := reflect.ValueOf(list)
+
+	ids := []primitive.ObjectID{}
+	seen := map[primitive.ObjectID]bool{}
+	for i := 0; i < docs.Len(); i++ {
+		doc := reflect.Indirect(docs.Index(i))
+		for _, id := range r.ids(doc) {
+			if !id.IsZero() && !seen[id] {
+				seen[id] = true
+				ids = append(ids, id)
+			}
+		}
+	}
+	if len(ids) == 0 {
+		return nil
+	}
+
+	found := map[primitive.ObjectID]reflect.Value{}
+	for start := 0; start < len(ids); start += refBatchSize {
+		end := start + refBatchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		m, err := r.store.findByIDs(ctx, ids[start:end])
+		if err != nil {
+			return err
+		}
+		for id, v := range m {
+			found[id] = v
+		}
+	}
+
+	for i := 0; i < docs.Len(); i++ {
+		doc := reflect.Indirect(docs.Index(i))
+		field := doc.FieldByIndex(r.field)
+		if !r.many {
+			if v, ok := found[r.ids(doc)[0]]; ok {
+				field.Set(v)
+			}
+			continue
+		}
+		values := reflect.MakeSlice(field.Type(), 0, 0)
+		for _, id := range r.ids(doc) {
+			if v, ok := found[id]; ok {
+				values = reflect.Append(values, v)
+			}
+		}
+		field.Set(values)
+	}
+	return nil
+}
+
+// ids returns the referenced ids of doc.
+func (r *reference) ids(doc reflect.Value) []primitive.ObjectID {
+	v := doc.FieldByIndex(r.id).Interface()
+	if r.many {
+		return v.([]primitive.ObjectID)
+	}
+	return []primitive.ObjectID{v.(primitive.ObjectID)}
+}
+
+func (s *Store[T]) refType() reflect.Type {
+	return reflect.TypeOf((*T)(nil)).Elem()
+}
+
+// findByIDs loads the documents with ids, keyed by id. Missing documents are left out.
+func (s *Store[T]) findByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]reflect.Value, error) {
+	list, err := s.getMany(ctx, ids)
+	if err != nil {
+		return nil, err
+	}
+	found := make(map[primitive.ObjectID]reflect.Value, len(list))
+	for id, o := range list {
+		found[id] = reflect.ValueOf(o)
+	}
+	return found, nil
+}
@@ -0,0 +1,94 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Playlist struct {
+	Document  `bson:",inline"`     // include mgm.DefaultModel
+	Name      string               `json:"name" bson:"name"`
+	MediumIds []primitive.ObjectID `json:"medium_ids" bson:"medium_ids"`
+	Media     []*Medium            `json:"media,omitempty" bson:"-" grimoire:"ref,medium_ids"`
+	Owner     *Medium              `json:"owner,omitempty" bson:"-" grimoire:"ref,name"`
+}
+
+// mediumRefs is a RefStore serving media from memory.
+type mediumRefs struct {
+	media map[primitive.ObjectID]*Medium
+	calls [][]primitive.ObjectID
+}
+
+func (r *mediumRefs) refType() reflect.Type {
+	return reflect.TypeOf(&Medium{})
+}
+
+func (r *mediumRefs) findByIDs(ctx context.Context, ids []primitive.ObjectID) (map[primitive.ObjectID]reflect.Value, error) {
+	r.calls = append(r.calls, ids)
+	found := map[primitive.ObjectID]reflect.Value{}
+	for _, id := range ids {
+		if m, ok := r.media[id]; ok {
+			found[id] = reflect.ValueOf(m)
+		}
+	}
+	return found, nil
+}
+
+func TestStore_SetRef(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	media, err := New[*Medium]("mongodb://localhost:27017", "seer_development", "media")
+	assert.NoError(t, err)
+	downloads, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+
+	assert.NoError(t, s.SetRef("Medium", media))
+	assert.True(t, errors.Is(s.SetRef("Missing", media), ErrUnknownRef))
+	assert.True(t, errors.Is(s.SetRef("Status", media), ErrUnknownRef), "no ref tag")
+	assert.Error(t, s.SetRef("Medium", downloads), "wrong type")
+
+	p, err := New[*Playlist]("mongodb://localhost:27017", "grimoire", "playlists")
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetRef("Media", media))
+	assert.Error(t, p.SetRef("Owner", media), "string id")
+}
+
+func TestQueryBuilder_Preload(t *testing.T) {
+	a := &Medium{Title: "a"}
+	a.ID = primitive.NewObjectID()
+	b := &Medium{Title: "b"}
+	b.ID = primitive.NewObjectID()
+	refs := &mediumRefs{media: map[primitive.ObjectID]*Medium{a.ID: a, b.ID: b}}
+
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NoError(t, s.SetRef("Medium", refs))
+
+	list := []*Download{{MediumId: a.ID}, {MediumId: b.ID}, {MediumId: a.ID}, {}, {MediumId: primitive.NewObjectID()}}
+	assert.NoError(t, s.Query().Preload("Medium").preload(context.Background(), list))
+	assert.Same(t, a, list[0].Medium)
+	assert.Same(t, b, list[1].Medium)
+	assert.Same(t, a, list[2].Medium)
+	assert.Nil(t, list[3].Medium)
+	assert.Nil(t, list[4].Medium)
+	if assert.Len(t, refs.calls, 1, "one batched query") {
+		assert.Len(t, refs.calls[0], 3, "distinct ids")
+	}
+
+	err = s.Query().Preload("Missing").preload(context.Background(), list)
+	assert.True(t, errors.Is(err, ErrUnknownRef))
+
+	p, err := New[*Playlist]("mongodb://localhost:27017", "grimoire", "playlists")
+	assert.NoError(t, err)
+	assert.NoError(t, p.SetRef("Media", refs))
+	playlists := []*Playlist{{MediumIds: []primitive.ObjectID{b.ID, a.ID}}, {}}
+	assert.NoError(t, p.Query().Preload("Media").preload(context.Background(), playlists))
+	assert.Equal(t, []*Medium{b, a}, playlists[0].Media)
+	assert.Empty(t, playlists[1].Media)
+}
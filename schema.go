@@ -0,0 +1,228 @@
+package grimoire
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+
+	"github.com/kamva/mgm/v3"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+const (
+	// ValidationLevelStrict validates all inserts and updates.
+	ValidationLevelStrict = "strict"
+	// ValidationLevelModerate validates inserts, and updates of documents that are already valid.
+	ValidationLevelModerate = "moderate"
+	// ValidationLevelOff disables validation.
+	ValidationLevelOff = "off"
+
+	// ValidationActionError rejects invalid documents.
+	ValidationActionError = "error"
+	// ValidationActionWarn logs invalid documents in the server log, and writes them anyway.
+	ValidationActionWarn = "warn"
+)
+
+// JSONSchema returns the $jsonSchema of the documents of the model T, built from the BSON
+// fields of T, including inline structs such as Document. Fields tagged with
+// `grimoire:"required"` are required, and fields tagged with `grimoire:"oneof=a b c"` only accept
+// the listed values. Pointers, slices and maps also accept null. Fields that are not in the model
+// are allowed.
+//
+// Example:
+//
+//	type Download struct {
+//		Document `bson:",inline"`
+//		Status   string `bson:"status" grimoire:"required,oneof=queued loading done"`
+//	}
+//
+//	schema, err := JSONSchema[*Download]()
+func JSONSchema[T mgm.Model]() (bson.M, error) {
+	return objectSchema(modelType[T](), 0)
+}
+
+// ApplyValidator sets the $jsonSchema of T as the validator of the collection, creating the
+// collection if it does not exist, see JSONSchema.
+// NOTE: level should be one of ValidationLevelStrict, ValidationLevelModerate or
+// ValidationLevelOff, and action one of ValidationActionError or ValidationActionWarn.
+//
+// Example:
+//
+//	err := s.ApplyValidator(ValidationLevelModerate, ValidationActionError)
+func (s *Store[T]) ApplyValidator(level, action string) error {
+	return s.ApplyValidatorWithContext(mgm.Ctx(), level, action)
+}
+
+// ApplyValidatorWithContext sets the validator of the collection with ctx, see ApplyValidator.
+func (s *Store[T]) ApplyValidatorWithContext(ctx context.Context, level, action string) error {
+	schema, err := JSONSchema[T]()
+	if err != nil {
+		return err
+	}
+	validator := bson.M{"$jsonSchema": schema}
+	name := s.Collection.Name()
+
+	names, err := s.Database.ListCollectionNames(ctx, bson.M{"name": name})
+	if err != nil {
+		return err
+	}
+	if len(names) == 0 {
+		opts := options.CreateCollection().
+			SetValidator(validator).
+			SetValidationLevel(level).
+			SetValidationAction(action)
+		return s.Database.CreateCollection(ctx, name, opts)
+	}
+
+	cmd := bson.D{
+		{Key: "collMod", Value: name},
+		{Key: "validator", Value: validator},
+		{Key: "validationLevel", Value: level},
+		{Key: "validationAction", Value: action},
+	}
+	return s.Database.RunCommand(ctx, cmd).Err()
+}
+
+// objectSchema returns the schema of the struct type t.
+func objectSchema(t reflect.Type, depth int) (bson.M, error) {
+	properties := bson.M{}
+	required := []string{}
+	if err := collectProperties(properties, &required, t, depth); err != nil {
+		return nil, err
+	}
+
+	schema := bson.M{"bsonType": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema, nil
+}
+
+// collectProperties adds the schemas of the fields of the struct type t to properties, merging
+// the fields of inline structs.
+func collectProperties(properties bson.M, required *[]string, t reflect.Type, depth int) error {
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name, inline, skip := bsonName(sf)
+		if skip {
+			continue
+		}
+		if inline {
+			if ft := indirect(sf.Type); ft.Kind() == reflect.Struct {
+				if err := collectProperties(properties, required, ft, depth+1); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+
+		schema, err := fieldSchema(sf.Type, depth+1)
+		if err != nil {
+			return fmt.Errorf("grimoire: schema of %s: %w", sf.Name, err)
+		}
+
+		tag, ok, err := parseTag(sf)
+		if err != nil {
+			return fmt.Errorf("grimoire: schema of %s: %w", sf.Name, err)
+		}
+		if ok {
+			if _, ok := tag.Rule("required"); ok {
+				*required = append(*required, name)
+			}
+			if values, ok := tag.Rule("oneof"); ok {
+				enum := bson.A{}
+				for _, v := range strings.Fields(values) {
+					value, err := coerce(sf.Type, v)
+					if err != nil {
+						return fmt.Errorf("grimoire: oneof of %s: %w", sf.Name, err)
+					}
+					enum = append(enum, value)
+				}
+				schema["enum"] = enum
+			}
+		}
+		properties[name] = schema
+	}
+	return nil
+}
+
+// fieldSchema returns the schema of a field of type t, as encoded by the driver.
+func fieldSchema(t reflect.Type, depth int) (bson.M, error) {
+	if depth > 8 {
+		return bson.M{}, nil
+	}
+
+	nullable := false
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+		nullable = true
+	}
+
+	schema := bson.M{}
+	switch t {
+	case timeType:
+		schema["bsonType"] = "date"
+	case objectIDType:
+		schema["bsonType"] = "objectId"
+	case symbolType:
+		schema["bsonType"] = bson.A{"symbol", "string"}
+	}
+
+	if schema["bsonType"] == nil {
+		switch t.Kind() {
+		case reflect.String:
+			schema["bsonType"] = "string"
+		case reflect.Bool:
+			schema["bsonType"] = "bool"
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+			// ints are encoded as int32 when they fit, int64 otherwise
+			schema["bsonType"] = bson.A{"int", "long"}
+		case reflect.Float32, reflect.Float64:
+			schema["bsonType"] = "double"
+		case reflect.Slice, reflect.Array:
+			if t.Elem().Kind() == reflect.Uint8 {
+				// nil byte slices are encoded as null
+				schema["bsonType"] = "binData"
+				nullable = nullable || t.Kind() == reflect.Slice
+				break
+			}
+			items, err := fieldSchema(t.Elem(), depth+1)
+			if err != nil {
+				return nil, err
+			}
+			schema["bsonType"] = "array"
+			if len(items) > 0 {
+				schema["items"] = items
+			}
+			nullable = nullable || t.Kind() == reflect.Slice
+		case reflect.Map:
+			schema["bsonType"] = "object"
+			nullable = true
+		case reflect.Struct:
+			s, err := objectSchema(t, depth)
+			if err != nil {
+				return nil, err
+			}
+			schema = s
+		case reflect.Interface:
+			return schema, nil // any type
+		default:
+			return nil, fmt.Errorf("unsupported type %s", t)
+		}
+	}
+
+	if nullable {
+		if types, ok := schema["bsonType"].(bson.A); ok {
+			schema["bsonType"] = append(types, "null")
+		} else {
+			schema["bsonType"] = bson.A{schema["bsonType"], "null"}
+		}
+	}
+	return schema, nil
+}
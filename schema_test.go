@@ -0,0 +1,72 @@
+package grimoire
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Task struct {
+	Document `bson:",inline"`
+	Title    string             `bson:"title" grimoire:"required"`
+	Status   string             `bson:"status" grimoire:"required,oneof=queued running done"`
+	Priority int                `bson:"priority" grimoire:"oneof=1 2 3"`
+	Kind     primitive.Symbol   `bson:"kind"`
+	Score    float64            `bson:"score"`
+	DueAt    *time.Time         `bson:"due_at,omitempty"`
+	Tags     []string           `bson:"tags"`
+	Data     []byte             `bson:"data"`
+	Meta     map[string]string  `bson:"meta"`
+	Extra    interface{}        `bson:"extra"`
+	Owner    primitive.ObjectID `bson:"owner_id" grimoire:"index"`
+	Steps    []struct {
+		Name string `bson:"name" grimoire:"required"`
+		Done bool   `bson:"done"`
+	} `bson:"steps"`
+	Ignored string `bson:"-"`
+	hidden  string
+}
+
+func TestJSONSchema(t *testing.T) {
+	schema, err := JSONSchema[*Task]()
+	assert.NoError(t, err)
+	assert.Equal(t, "object", schema["bsonType"])
+	assert.Equal(t, []string{"title", "status"}, schema["required"])
+
+	props := schema["properties"].(bson.M)
+	assert.Len(t, props, 15)
+	assert.Equal(t, bson.M{"bsonType": "objectId"}, props["_id"], "inline Document")
+	assert.Equal(t, bson.M{"bsonType": "date"}, props["created_at"])
+	assert.Equal(t, bson.M{"bsonType": "string"}, props["title"])
+	assert.Equal(t, bson.M{"bsonType": "string", "enum": bson.A{"queued", "running", "done"}}, props["status"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"int", "long"}, "enum": bson.A{int64(1), int64(2), int64(3)}}, props["priority"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"symbol", "string"}}, props["kind"])
+	assert.Equal(t, bson.M{"bsonType": "double"}, props["score"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"date", "null"}}, props["due_at"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"array", "null"}, "items": bson.M{"bsonType": "string"}}, props["tags"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"binData", "null"}}, props["data"])
+	assert.Equal(t, bson.M{"bsonType": bson.A{"object", "null"}}, props["meta"])
+	assert.Equal(t, bson.M{}, props["extra"])
+	assert.NotContains(t, props, "Ignored")
+	assert.NotContains(t, props, "hidden")
+
+	steps := props["steps"].(bson.M)
+	assert.Equal(t, bson.A{"array", "null"}, steps["bsonType"])
+	assert.Equal(t, bson.M{
+		"bsonType": "object",
+		"properties": bson.M{
+			"name": bson.M{"bsonType": "string"},
+			"done": bson.M{"bsonType": "bool"},
+		},
+		"required": []string{"name"},
+	}, steps["items"])
+
+	_, err = JSONSchema[*struct {
+		Document `bson:",inline"`
+		Size     int `bson:"size" grimoire:"oneof=small"`
+	}]()
+	assert.Error(t, err)
+}
@@ -0,0 +1,161 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+// ErrUnknownScope is returned when a query uses a scope that is not registered on the store.
+var ErrUnknownScope = errors.New("unknown scope")
+
+// QueryDefaultsScope is the name of the default scope holding the clauses set with
+// SetQueryDefaults.
+const QueryDefaultsScope = "defaults"
+
+// RegisterScope registers a named scope on the store, which queries can apply with Scope, or
+// which can be added to the default scopes with SetDefaultScopes. Registering a name again
+// replaces the scope.
+//
+// Example:
+//
+//	s.RegisterScope("active", func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+//		return q.In("status", []string{"searching", "loading", "downloading"})
+//	})
+func (s *Store[T]) RegisterScope(name string, scope Scope[T]) {
+	s.scopesMu.Lock()
+	defer s.scopesMu.Unlock()
+
+	if s.scopes == nil {
+		s.scopes = map[string]Scope[T]{}
+	}
+	s.scopes[name] = scope
+}
+
+// SetDefaultScopes sets the named scopes applied to every query of the store, including Count,
+// Raw and DeleteMany, replacing the previous default scopes. The clauses of the default scopes
+// are added when the query runs, other options they set are ignored. Queries opt out with
+// Unscoped or Without.
+//
+// Example:
+//
+//	err := s.SetDefaultScopes("active")
+func (s *Store[T]) SetDefaultScopes(names ...string) error {
+	s.scopesMu.Lock()
+	defer s.scopesMu.Unlock()
+
+	for _, name := range names {
+		if _, ok := s.scopes[name]; !ok {
+			return fmt.Errorf("grimoire: %w: %s", ErrUnknownScope, name)
+		}
+	}
+	s.defaultScopes = append([]string{}, names...)
+	return nil
+}
+
+// scope returns the registered scope name.
+func (s *Store[T]) scope(name string) (Scope[T], error) {
+	s.scopesMu.RLock()
+	defer s.scopesMu.RUnlock()
+
+	scope, ok := s.scopes[name]
+	if !ok {
+		return nil, fmt.Errorf("grimoire: %w: %s", ErrUnknownScope, name)
+	}
+	return scope, nil
+}
+
+// defaultClauses returns the clauses of the default scopes, except those named in without.
+func (s *Store[T]) defaultClauses(without []string) []bson.M {
+	s.scopesMu.RLock()
+	scopes := make([]Scope[T], 0, len(s.defaultScopes))
+	for _, name := range s.defaultScopes {
+		if !contains(without, name) {
+			scopes = append(scopes, s.scopes[name])
+		}
+	}
+	s.scopesMu.RUnlock()
+
+	values := []bson.M{}
+	for _, scope := range scopes {
+		qq := scope(s.sub())
+		values = append(values, qq.values...)
+	}
+	return values
+}
+
+// scopedFilter returns query combined with the clauses of the default scopes, except those
+// named in without.
+func (s *Store[T]) scopedFilter(query bson.M, without []string) bson.M {
+	values := s.defaultClauses(without)
+	if len(values) == 0 {
+		return query
+	}
+	if len(query) > 0 {
+		values = append(values, query)
+	}
+	return bson.M{"$and": values}
+}
+
+// Scope applies the named scopes registered on the store to the query. Using a scope that is
+// not registered makes the query fail with ErrUnknownScope.
+//
+// Example:
+//
+//	Scope("active", "recent")
+func (q *QueryBuilder[T]) Scope(names ...string) *QueryBuilder[T] {
+	for _, name := range names {
+		scope, err := q.store.scope(name)
+		if err != nil {
+			q = q.mutable()
+			q.err = err
+			return q
+		}
+		q = scope(q)
+	}
+	return q
+}
+
+// Unscoped removes the default scopes of the store from the query.
+func (q *QueryBuilder[T]) Unscoped() *QueryBuilder[T] {
+	q = q.mutable()
+	q.unscoped = true
+	return q
+}
+
+// Without removes the named default scopes of the store from the query.
+//
+// Example:
+//
+//	Without("active")
+func (q *QueryBuilder[T]) Without(names ...string) *QueryBuilder[T] {
+	q = q.mutable()
+	q.without = append(q.without, names...)
+	return q
+}
+
+// clauses returns the tenant clause and the clauses of the default scopes that apply to the
+// query, followed by the clauses of the query. The tenant clause is never removed.
+func (q *QueryBuilder[T]) clauses() []bson.M {
+	var values []bson.M
+	if tenant := q.store.tenantClause(q.context()); tenant != nil {
+		values = append(values, tenant)
+	}
+	if !q.unscoped {
+		values = append(values, q.store.defaultClauses(q.without)...)
+	}
+	if len(values) == 0 {
+		return q.values
+	}
+	return append(values, q.values...)
+}
+
+func contains(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,77 @@
+package grimoire
+
+import (
+	"errors"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestStore_Scopes(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	s.RegisterScope("active", func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.In("status", []string{"searching", "loading"})
+	})
+	s.RegisterScope("recent", func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+		return q.Desc("created_at").Limit(10)
+	})
+
+	q := s.Query().Where("_type", "Movie").Scope("active", "recent")
+	assert.NoError(t, q.err)
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"_type": bson.M{"$eq": "Movie"}},
+		{"status": bson.M{"$in": []string{"searching", "loading"}}},
+	}}, q.filter())
+	assert.Equal(t, bson.D{{Key: "created_at", Value: -1}}, q.sort)
+	assert.Equal(t, int64(10), q.limit)
+
+	q = s.Query().Scope("missing")
+	assert.True(t, errors.Is(q.before("find"), ErrUnknownScope))
+	_, err = q.Raw(bson.M{})
+	assert.True(t, errors.Is(err, ErrUnknownScope))
+
+	assert.True(t, errors.Is(s.SetDefaultScopes("missing"), ErrUnknownScope))
+	assert.NoError(t, s.SetDefaultScopes("active"))
+	s.SetQueryDefaults([]bson.M{{"auto": true}})
+
+	active := bson.M{"status": bson.M{"$in": []string{"searching", "loading"}}}
+	q = s.Query().Where("_type", "Movie")
+	assert.Equal(t, []bson.M{{"_type": bson.M{"$eq": "Movie"}}}, q.values, "defaults are not stored on the query")
+	assert.Equal(t, bson.M{"$and": []bson.M{active, {"auto": true}, {"_type": bson.M{"$eq": "Movie"}}}}, q.filter())
+	assert.Equal(t, []string{"_type", "auto", "status"}, q.Shape().Equality)
+
+	assert.Equal(t, bson.M{"$and": []bson.M{{"_type": bson.M{"$eq": "Movie"}}}}, q.Clone().Unscoped().filter())
+	assert.Equal(t, bson.M{"$and": []bson.M{active, {"_type": bson.M{"$eq": "Movie"}}}}, q.Clone().Without(QueryDefaultsScope).filter())
+
+	assert.Equal(t, bson.M{"$and": []bson.M{active, {"auto": true}, {"_id": "abc"}}}, s.scopedFilter(bson.M{"_id": "abc"}, nil))
+	assert.Equal(t, bson.M{"$and": []bson.M{{"auto": true}}}, s.scopedFilter(bson.M{}, []string{"active"}))
+
+	// nested conditions do not repeat the defaults
+	q = s.Query().Or(func(q *QueryBuilder[*Download]) {
+		q.Where("a", 1)
+	}, func(q *QueryBuilder[*Download]) {
+		q.Where("b", 2)
+	})
+	assert.Equal(t, bson.M{"$and": []bson.M{active, {"auto": true}, {"$or": []bson.M{
+		{"a": bson.M{"$eq": 1}},
+		{"b": bson.M{"$eq": 2}},
+	}}}}, q.filter())
+
+	wg := sync.WaitGroup{}
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			s.RegisterScope("recent", func(q *QueryBuilder[*Download]) *QueryBuilder[*Download] {
+				return q.Desc("created_at")
+			})
+			_ = s.Query().Scope("recent").filter()
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,322 @@
+package grimoire
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+// Shell returns the query as a mongosh command, which can be pasted into a shell connected to
+// the database of the store. Values are written with the shell constructors, such as ObjectId
+// and ISODate.
+// NOTE: query builders without a store, e.g. decoded from JSON into a zero value, are written for
+// db.collection, without default scopes.
+//
+// Example:
+//
+//	db.downloads.find({"$and": [{"status": {"$eq": "done"}}]}).sort({"created_at": -1}).limit(25)
+func (q *QueryBuilder[T]) Shell() string {
+	if q.store == nil {
+		return q.shell(filterOf(q.values))
+	}
+	return q.shell(q.filter())
+}
+
+func (q *QueryBuilder[T]) shell(filter interface{}) string {
+	name := "collection"
+	if q.store != nil && q.store.Collection != nil {
+		name = q.store.Collection.Name()
+	}
+
+	var b strings.Builder
+	b.WriteString(shellCollection(name))
+	b.WriteString(".find(")
+	b.WriteString(shellValue(filter))
+	if len(q.projection) > 0 {
+		b.WriteString(", ")
+		b.WriteString(shellValue(q.projection))
+	}
+	b.WriteString(")")
+	if len(q.sort) > 0 {
+		b.WriteString(".sort(" + shellValue(q.sort) + ")")
+	}
+	if q.collation != nil {
+		b.WriteString(".collation(" + shellValue(q.collation.ToDocument()) + ")")
+	}
+	if q.skip > 0 {
+		b.WriteString(fmt.Sprintf(".skip(%d)", q.skip))
+	}
+	if q.limit > 0 {
+		b.WriteString(fmt.Sprintf(".limit(%d)", q.limit))
+	}
+	if q.hint != nil {
+		b.WriteString(".hint(" + shellValue(q.hint) + ")")
+	}
+	if q.maxTime > 0 {
+		b.WriteString(fmt.Sprintf(".maxTimeMS(%d)", q.maxTime.Milliseconds()))
+	}
+	if q.allowDiskUse {
+		b.WriteString(".allowDiskUse()")
+	}
+	if q.batchSize > 0 {
+		b.WriteString(fmt.Sprintf(".batchSize(%d)", q.batchSize))
+	}
+	if q.noCursorTimeout {
+		b.WriteString(".noCursorTimeout()")
+	}
+	if q.comment != "" {
+		b.WriteString(".comment(" + shellString(q.comment) + ")")
+	}
+	if q.readPref != nil {
+		b.WriteString(".readPref(" + shellString(q.readPref.Mode().String()) + ")")
+	}
+	if q.readConcern != nil && q.readConcern.Level != "" {
+		b.WriteString(".readConcern(" + shellString(q.readConcern.Level) + ")")
+	}
+	return b.String()
+}
+
+// ExtJSON returns the filter of the query as canonical Extended JSON.
+// NOTE: like Shell, query builders without a store are written without default scopes.
+func (q *QueryBuilder[T]) ExtJSON() (string, error) {
+	var filter interface{}
+	if q.store == nil {
+		filter = filterOf(q.values)
+	} else {
+		filter = q.filter()
+	}
+	data, err := bson.MarshalExtJSON(filter, true, false)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// queryJSON is the JSON representation of a query builder. Documents are stored as
+// canonical Extended JSON, so that types such as ObjectIDs and dates are preserved.
+type queryJSON struct {
+	Filter     json.RawMessage    `json:"filter"`
+	Projection json.RawMessage    `json:"projection,omitempty"`
+	Sort       json.RawMessage    `json:"sort,omitempty"`
+	Collation  *options.Collation `json:"collation,omitempty"`
+	Skip       int64              `json:"skip,omitempty"`
+	Limit      int64              `json:"limit,omitempty"`
+}
+
+// MarshalJSON encodes the filter, projection, sort, collation, skip and limit of the query, so
+// that it can be saved and restored later with UnmarshalJSON. The default scopes of the store
+// are not included, they apply again when the restored query runs.
+func (q *QueryBuilder[T]) MarshalJSON() ([]byte, error) {
+	filter, err := bson.MarshalExtJSON(filterOf(q.values), true, false)
+	if err != nil {
+		return nil, err
+	}
+
+	out := queryJSON{Filter: filter, Collation: q.collation, Skip: q.skip, Limit: q.limit}
+	if len(q.projection) > 0 {
+		if out.Projection, err = bson.MarshalExtJSON(q.projection, true, false); err != nil {
+			return nil, err
+		}
+	}
+	if len(q.sort) > 0 {
+		if out.Sort, err = bson.MarshalExtJSON(q.sort, true, false); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(out)
+}
+
+// UnmarshalJSON restores a query encoded with MarshalJSON, replacing the filter, projection,
+// sort, collation, skip and limit of q. The query builder should be created by the store
+// the query is run against.
+//
+// Example:
+//
+//	q := s.Query()
+//	err := json.Unmarshal(data, q)
+func (q *QueryBuilder[T]) UnmarshalJSON(data []byte) error {
+	in := queryJSON{}
+	if err := json.Unmarshal(data, &in); err != nil {
+		return err
+	}
+
+	filter := bson.M{}
+	if len(in.Filter) > 0 {
+		if err := bson.UnmarshalExtJSON(in.Filter, true, &filter); err != nil {
+			return fmt.Errorf("grimoire: filter: %w", err)
+		}
+	}
+	values := make([]bson.M, 0)
+	if and, ok := filter["$and"].(bson.A); ok && len(filter) == 1 {
+		for _, v := range and {
+			m, ok := v.(bson.M)
+			if !ok {
+				return fmt.Errorf("grimoire: filter: invalid $and value %v", v)
+			}
+			values = append(values, m)
+		}
+	} else if len(filter) > 0 {
+		values = append(values, filter)
+	}
+
+	projection := bson.D{}
+	if len(in.Projection) > 0 {
+		if err := bson.UnmarshalExtJSON(in.Projection, true, &projection); err != nil {
+			return fmt.Errorf("grimoire: projection: %w", err)
+		}
+	}
+	sort := bson.D{}
+	if len(in.Sort) > 0 {
+		if err := bson.UnmarshalExtJSON(in.Sort, true, &sort); err != nil {
+			return fmt.Errorf("grimoire: sort: %w", err)
+		}
+	}
+
+	q.values = values
+	q.projection = projection
+	q.sort = sort
+	q.collation = in.Collation
+	q.skip = in.Skip
+	q.limit = in.Limit
+	return nil
+}
+
+var shellIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+func shellCollection(name string) string {
+	if shellIdentifier.MatchString(name) {
+		return "db." + name
+	}
+	return "db.getCollection(" + shellString(name) + ")"
+}
+
+func shellString(s string) string {
+	var b strings.Builder
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	_ = enc.Encode(s) // encoding a string cannot fail
+	return strings.TrimSuffix(b.String(), "\n")
+}
+
+// shellValue formats value as a mongosh literal.
+func shellValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bson.Raw:
+		doc := bson.D{}
+		if err := bson.Unmarshal(v, &doc); err != nil {
+			return "null"
+		}
+		return shellValue(doc)
+	case string:
+		return shellString(v)
+	case primitive.Symbol:
+		return shellString(string(v))
+	case bool:
+		return strconv.FormatBool(v)
+	case int, int8, int16, int32, uint, uint8, uint16, uint32:
+		return fmt.Sprintf("%d", v)
+	case int64:
+		return fmt.Sprintf("NumberLong(%q)", strconv.FormatInt(v, 10))
+	case uint64:
+		return fmt.Sprintf("NumberLong(%q)", strconv.FormatUint(v, 10))
+	case float32:
+		return shellFloat(float64(v))
+	case float64:
+		return shellFloat(v)
+	case time.Time:
+		return fmt.Sprintf("ISODate(%q)", v.UTC().Format("2006-01-02T15:04:05.000Z"))
+	case primitive.DateTime:
+		return shellValue(v.Time())
+	case primitive.ObjectID:
+		return fmt.Sprintf("ObjectId(%q)", v.Hex())
+	case primitive.Regex:
+		return "/" + strings.ReplaceAll(v.Pattern, "/", `\/`) + "/" + v.Options
+	case primitive.Decimal128:
+		return fmt.Sprintf("NumberDecimal(%q)", v.String())
+	case primitive.Binary:
+		return fmt.Sprintf("BinData(%d, %q)", v.Subtype, base64.StdEncoding.EncodeToString(v.Data))
+	case primitive.Timestamp:
+		return fmt.Sprintf("Timestamp({t: %d, i: %d})", v.T, v.I)
+	case primitive.Null:
+		return "null"
+	case bson.D:
+		parts := make([]string, 0, len(v))
+		for _, e := range v {
+			parts = append(parts, shellString(e.Key)+": "+shellValue(e.Value))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	case bson.M:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		parts := make([]string, 0, len(v))
+		for _, k := range keys {
+			parts = append(parts, shellString(k)+": "+shellValue(v[k]))
+		}
+		return "{" + strings.Join(parts, ", ") + "}"
+	}
+
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		parts := make([]string, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			parts = append(parts, shellValue(rv.Index(i).Interface()))
+		}
+		return "[" + strings.Join(parts, ", ") + "]"
+	case reflect.Map:
+		if rv.Type().Key().Kind() == reflect.String {
+			m := bson.M{}
+			for _, k := range rv.MapKeys() {
+				m[k.String()] = rv.MapIndex(k).Interface()
+			}
+			return shellValue(m)
+		}
+	case reflect.Ptr:
+		if rv.IsNil() {
+			return "null"
+		}
+		return shellValue(rv.Elem().Interface())
+	}
+
+	// anything else, such as structs, is converted using its BSON representation
+	data, err := bson.Marshal(bson.M{"v": value})
+	if err != nil {
+		return fmt.Sprintf("undefined /* %s */", err)
+	}
+	doc := bson.D{}
+	if err := bson.Unmarshal(data, &doc); err != nil || len(doc) != 1 {
+		return fmt.Sprintf("undefined /* %v */", err)
+	}
+	if reflect.TypeOf(doc[0].Value) == rv.Type() {
+		return fmt.Sprintf("undefined /* %T */", value)
+	}
+	return shellValue(doc[0].Value)
+}
+
+func shellFloat(f float64) string {
+	switch {
+	case math.IsNaN(f):
+		return "NaN"
+	case math.IsInf(f, 1):
+		return "Infinity"
+	case math.IsInf(f, -1):
+		return "-Infinity"
+	}
+	return strconv.FormatFloat(f, 'g', -1, 64)
+}
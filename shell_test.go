@@ -0,0 +1,79 @@
+package grimoire
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestQueryBuilder_Shell(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	id, err := primitive.ObjectIDFromHex("65f1c6a8e4b0a1b2c3d4e5f6")
+	assert.NoError(t, err)
+	since := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	q := s.Query().
+		Where("medium_id", id).
+		GreaterThan("created_at", since).
+		StartsWith("url", "https://").
+		Select("status", "url").
+		Desc("created_at").
+		Skip(10)
+
+	assert.Equal(t, `db.downloads.find({"$and": [{"medium_id": {"$eq": ObjectId("65f1c6a8e4b0a1b2c3d4e5f6")}}, {"created_at": {"$gt": ISODate("2024-03-01T12:00:00.000Z")}}, {"url": {"$regex": /^https:\/\//}}]}, {"status": 1, "url": 1}).sort({"created_at": -1}).skip(10).limit(25)`, q.Shell())
+	assert.Equal(t, q.Shell(), q.String())
+
+	ext, err := q.ExtJSON()
+	assert.NoError(t, err)
+	assert.Contains(t, ext, `{"$oid":"65f1c6a8e4b0a1b2c3d4e5f6"}`)
+	assert.Contains(t, ext, `{"$date":{"$numberLong":"1709294400000"}}`)
+}
+
+func TestQueryBuilder_JSON(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	id := primitive.NewObjectID()
+	q := s.Query().
+		Where("medium_id", id).
+		In("status", []string{"searching", "loading"}).
+		EqualFold("thash", "ABC").
+		Exclude("download_files").
+		Desc("created_at").
+		Asc("url").
+		Skip(5).
+		Limit(50)
+
+	data, err := json.Marshal(q)
+	assert.NoError(t, err)
+
+	restored := s.Query()
+	err = json.Unmarshal(data, restored)
+	assert.NoError(t, err)
+
+	assert.Equal(t, q.Shell(), restored.Shell())
+	assert.Equal(t, id, restored.values[0]["medium_id"].(bson.M)["$eq"])
+	assert.Equal(t, bson.D{{Key: "created_at", Value: int32(-1)}, {Key: "url", Value: int32(1)}}, restored.sort)
+	assert.Equal(t, q.collation, restored.collation)
+	assert.Equal(t, int64(5), restored.skip)
+	assert.Equal(t, int64(50), restored.limit)
+
+	// no store
+	zero := &QueryBuilder[*Download]{}
+	assert.NoError(t, json.Unmarshal(data, zero))
+	assert.True(t, strings.HasPrefix(zero.String(), `db.collection.find({"$and": [{"medium_id": {"$eq": ObjectId(`), zero.String())
+	assert.Equal(t, "db.collection.find({})", fmt.Sprint(&QueryBuilder[*Download]{}))
+	ext, err := zero.ExtJSON()
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(ext, `{"$and":[{"medium_id":{"$eq":{"$oid":"`+id.Hex()+`"}}}`), ext)
+}
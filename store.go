@@ -1,8 +1,11 @@
 package grimoire
 
 import (
+	"context"
 	"reflect"
 	"strings"
+	"sync"
+	"sync/atomic"
 
 	"github.com/kamva/mgm/v3"
 	"go.mongodb.org/mongo-driver/bson"
@@ -11,35 +14,35 @@ import (
 )
 
 type Store[T mgm.Model] struct {
-	Client        *mongo.Client
-	Database      *mongo.Database
-	Collection    *mgm.Collection
-	queryDefaults []bson.M
+	Client          *mongo.Client
+	Database        *mongo.Database
+	Collection      *mgm.Collection
+	queryDefaults   []bson.M
+	scopes          map[string]Scope[T]
+	defaultScopes   []string
+	scopesMu        sync.RWMutex
+	tenantField     string
+	refs            map[string]*reference
+	strict          StrictOptions
+	advisor         atomic.Pointer[IndexAdvisor]
+	instrumentation *instrumentation
+	logging         *logging
 }
 
 // CreateIndexes creates indexes on the collection
 // descriptor is a string of index specs separated by semicolons
-// each spec is a comma separated list of fields, with an optional direction
+// each spec is a comma separated list of fields, with an optional direction, see ParseIndexSpec
 func CreateIndexes[T mgm.Model](s *Store[T], o T, descriptor string) {
 	if descriptor == "" {
 		return
 	}
 
-	specs := strings.Split(descriptor, ";")
-	for _, spec := range specs {
-		d := bson.D{}
-		fields := strings.Split(spec, ",")
-		for _, field := range fields {
-			parts := strings.Split(field, ":")
-			if len(parts) > 1 {
-				if parts[1] == "desc" || parts[1] == "-1" {
-					d = append(d, bson.E{Key: parts[0], Value: -1})
-				} else if parts[1] == "text" {
-					d = append(d, bson.E{Key: parts[0], Value: "text"})
-				}
-			}
-		}
-		s.Collection.Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{Keys: d})
+	indexes, err := ParseIndexSpec(descriptor)
+	if err != nil {
+		return
+	}
+	for _, keys := range indexes {
+		s.Collection.Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{Keys: keys})
 	}
 }
 
@@ -51,33 +54,34 @@ func Indexes[T mgm.Model](s *Store[T], o T) {
 
 // Indexes creates indexes on the collection based on struct tags
 func CreateIndexesFromTags[T mgm.Model](s *Store[T], o T) {
-	t := reflect.TypeOf(o)
+	for _, keys := range tagIndexes(reflect.TypeOf(o)) {
+		s.Collection.Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{Keys: keys})
+	}
+}
+
+// tagIndexes returns the keys of the indexes declared with grimoire struct tags on t
+func tagIndexes(t reflect.Type) []bson.D {
 	if t.Kind() == reflect.Ptr {
 		t = t.Elem()
 	}
 
+	indexes := []bson.D{}
 	for i := 0; i < t.NumField(); i++ {
 		field := t.Field(i)
-		if v, ok := field.Tag.Lookup("grimoire"); ok {
+		tag, ok, err := parseTag(field)
+		if err != nil || !ok || tag.Index == 0 {
+			continue // invalid tags are returned by Validate
+		}
+		name := strings.ToLower(field.Name) // default to field name
+		if v, ok := field.Tag.Lookup("bson"); ok {
 			vals := strings.Split(v, ",")
-			if vals[0] == "index" {
-				dir := 1
-				if len(vals) > 1 {
-					if vals[1] == "desc" {
-						dir = -1
-					}
-				}
-				name := strings.ToLower(field.Name) // default to field name
-				if v, ok := field.Tag.Lookup("bson"); ok {
-					vals := strings.Split(v, ",")
-					if len(vals) > 0 {
-						name = vals[0] // use bson tag if available
-					}
-				}
-				s.Collection.Indexes().CreateOne(mgm.Ctx(), mongo.IndexModel{Keys: bson.M{name: dir}})
+			if len(vals) > 0 {
+				name = vals[0] // use bson tag if available
 			}
 		}
+		indexes = append(indexes, bson.D{{Key: name, Value: tag.Index}})
 	}
+	return indexes
 }
 
 // New creates a new store object
@@ -100,38 +104,78 @@ func New[T mgm.Model](URI, database, collection string) (*Store[T], error) {
 }
 
 // SetQueryDefaults sets defaults used for all queries
+// the defaults are the default scope named QueryDefaultsScope, see SetDefaultScopes
 func (s *Store[T]) SetQueryDefaults(values []bson.M) {
+	s.scopesMu.Lock()
+	defer s.scopesMu.Unlock()
+
 	s.queryDefaults = append(s.queryDefaults, values...)
+	defaults := append([]bson.M{}, s.queryDefaults...)
+	if s.scopes == nil {
+		s.scopes = map[string]Scope[T]{}
+	}
+	s.scopes[QueryDefaultsScope] = func(q *QueryBuilder[T]) *QueryBuilder[T] {
+		return q.add(defaults...)
+	}
+	if !contains(s.defaultScopes, QueryDefaultsScope) {
+		s.defaultScopes = append(s.defaultScopes, QueryDefaultsScope)
+	}
 }
 
 func (s *Store[T]) GetByID(id primitive.ObjectID, out T) (T, error) {
-	err := s.Collection.FindByID(id, out)
+	return s.GetByIDWithContext(mgm.Ctx(), id, out)
+}
+
+// GetByIDWithContext is GetByID with a context, which carries the tenant of tenant stores.
+func (s *Store[T]) GetByIDWithContext(ctx context.Context, id primitive.ObjectID, out T) (T, error) {
+	err := s.FindByIDWithContext(ctx, id, out)
 	return out, err
 }
 
 func (s *Store[T]) Get(id string, out T) (T, error) {
+	return s.GetWithContext(mgm.Ctx(), id, out)
+}
+
+// GetWithContext is Get with a context, which carries the tenant of tenant stores.
+func (s *Store[T]) GetWithContext(ctx context.Context, id string, out T) (T, error) {
 	oid, err := idFromHex(id)
 	if err != nil {
 		return out, err
 	}
-	return s.GetByID(oid, out)
+	return s.GetByIDWithContext(ctx, oid, out)
 }
 
 func (s *Store[T]) FindByID(id primitive.ObjectID, out T) error {
-	err := s.Collection.FindByID(id, out)
+	return s.FindByIDWithContext(mgm.Ctx(), id, out)
+}
+
+// FindByIDWithContext is FindByID with a context, which carries the tenant of tenant stores.
+// Documents of other tenants are not found.
+func (s *Store[T]) FindByIDWithContext(ctx context.Context, id primitive.ObjectID, out T) error {
+	filter, err := s.tenantFilter(ctx, bson.M{"_id": id})
 	if err != nil {
 		return err
 	}
-
-	return nil
+	return s.observe(ctx, operation{name: "find", filter: filter}, func(ctx context.Context) (int64, error) {
+		err := s.Collection.FirstWithCtx(ctx, filter, out)
+		if err != nil {
+			return 0, err
+		}
+		return 1, nil
+	})
 }
 
 func (s *Store[T]) Find(id string, out T) error {
+	return s.FindWithContext(mgm.Ctx(), id, out)
+}
+
+// FindWithContext is Find with a context, which carries the tenant of tenant stores.
+func (s *Store[T]) FindWithContext(ctx context.Context, id string, out T) error {
 	oid, err := idFromHex(id)
 	if err != nil {
 		return err
 	}
-	return s.FindByID(oid, out)
+	return s.FindByIDWithContext(ctx, oid, out)
 }
 
 func idFromHex(id string) (primitive.ObjectID, error) {
@@ -143,42 +187,152 @@ func idFromHex(id string) (primitive.ObjectID, error) {
 }
 
 func (s *Store[T]) Save(o T) error {
+	return s.SaveWithContext(mgm.Ctx(), o)
+}
+
+// SaveWithContext is Save with a context, which carries the tenant of tenant stores.
+func (s *Store[T]) SaveWithContext(ctx context.Context, o T) error {
 	if o.GetID().(primitive.ObjectID).IsZero() {
-		return s.Collection.Create(o)
+		if err := s.stampTenant(ctx, o); err != nil {
+			return err
+		}
+		if err := Validate(o); err != nil {
+			return err
+		}
+		return s.observe(ctx, operation{name: "insert"}, func(ctx context.Context) (int64, error) {
+			return 1, s.Collection.CreateWithCtx(ctx, o)
+		})
 	}
-	return s.Collection.Update(o)
+	return s.UpdateWithContext(ctx, o)
 }
 
 func (s *Store[T]) CreateWithTransaction(o T) error {
-	return mgm.TransactionWithClient(mgm.Ctx(), s.Client, func(session mongo.Session, ctx mongo.SessionContext) error {
-		err := s.Collection.CreateWithCtx(ctx, o)
-		if err != nil {
-			return err
-		}
-		return session.CommitTransaction(ctx)
+	return s.CreateWithTransactionWithContext(mgm.Ctx(), o)
+}
+
+// CreateWithTransactionWithContext is CreateWithTransaction with a context, which carries the
+// tenant of tenant stores.
+func (s *Store[T]) CreateWithTransactionWithContext(ctx context.Context, o T) error {
+	if err := s.stampTenant(ctx, o); err != nil {
+		return err
+	}
+	if err := Validate(o); err != nil {
+		return err
+	}
+	return s.observe(ctx, operation{name: "insert"}, func(ctx context.Context) (int64, error) {
+		return 1, mgm.TransactionWithClient(ctx, s.Client, func(session mongo.Session, ctx mongo.SessionContext) error {
+			err := s.Collection.CreateWithCtx(ctx, o)
+			if err != nil {
+				return err
+			}
+			return session.CommitTransaction(ctx)
+		})
 	})
 }
 
 func (s *Store[T]) Update(o T) error {
-	return s.Collection.Update(o)
+	return s.UpdateWithContext(mgm.Ctx(), o)
+}
+
+// UpdateWithContext is Update with a context, which carries the tenant of tenant stores.
+// Documents of other tenants are not updated. When no document matches, the error is
+// mongo.ErrNoDocuments.
+func (s *Store[T]) UpdateWithContext(ctx context.Context, o T) error {
+	if err := s.stampTenant(ctx, o); err != nil {
+		return err
+	}
+	if err := Validate(o); err != nil {
+		return err
+	}
+	filter, err := s.tenantFilter(ctx, bson.M{"_id": o.GetID()})
+	if err != nil {
+		return err
+	}
+	if err := beforeUpdate(ctx, o); err != nil {
+		return err
+	}
+
+	var res *mongo.UpdateResult
+	err = s.observe(ctx, operation{name: "update", filter: filter}, func(ctx context.Context) (int64, error) {
+		res, err = s.Collection.UpdateOne(ctx, filter, bson.M{"$set": o})
+		if err != nil {
+			return 0, err
+		}
+		if res.MatchedCount == 0 {
+			return 0, mongo.ErrNoDocuments
+		}
+		return res.ModifiedCount, nil
+	})
+	if err != nil {
+		return err
+	}
+	return afterUpdate(ctx, res, o)
 }
 
 func (s *Store[T]) Delete(o T) error {
-	return s.Collection.Delete(o)
+	return s.DeleteWithContext(mgm.Ctx(), o)
+}
+
+// DeleteWithContext is Delete with a context, which carries the tenant of tenant stores.
+// Documents of other tenants are not deleted. When no document matches, the error is
+// mongo.ErrNoDocuments.
+func (s *Store[T]) DeleteWithContext(ctx context.Context, o T) error {
+	filter, err := s.tenantFilter(ctx, bson.M{"_id": o.GetID()})
+	if err != nil {
+		return err
+	}
+	if err := beforeDelete(ctx, o); err != nil {
+		return err
+	}
+
+	var res *mongo.DeleteResult
+	err = s.observe(ctx, operation{name: "delete", filter: filter}, func(ctx context.Context) (int64, error) {
+		res, err = s.Collection.DeleteOne(ctx, filter)
+		if err != nil {
+			return 0, err
+		}
+		if res.DeletedCount == 0 {
+			return 0, mongo.ErrNoDocuments
+		}
+		return res.DeletedCount, nil
+	})
+	if err != nil {
+		return err
+	}
+	return afterDelete(ctx, res, o)
 }
 
+// Count returns the number of objects matching query and the default scopes.
 func (s *Store[T]) Count(query bson.M) (int64, error) {
-	return s.Collection.CountDocuments(mgm.Ctx(), query)
+	return s.CountWithContext(mgm.Ctx(), query)
 }
 
-func (s *Store[T]) Query() *QueryBuilder[T] {
-	values := make([]bson.M, 0)
-	if len(s.queryDefaults) > 0 {
-		values = append(values, s.queryDefaults...)
+// CountWithContext is Count with a context, which carries the tenant of tenant stores.
+func (s *Store[T]) CountWithContext(ctx context.Context, query bson.M) (int64, error) {
+	query, err := s.tenantFilter(ctx, s.scopedFilter(query, nil))
+	if err != nil {
+		return 0, err
 	}
+
+	var total int64
+	err = s.observe(ctx, operation{name: "count", filter: query}, func(ctx context.Context) (int64, error) {
+		n, err := s.Collection.CountDocuments(ctx, query)
+		total = n
+		return n, err
+	})
+	return total, err
+}
+
+func (s *Store[T]) Query() *QueryBuilder[T] {
+	return s.sub()
+}
+
+// sub returns an empty query builder on the store. The default scopes are applied when a query
+// runs, so they are not added to nested conditions.
+func (s *Store[T]) sub() *QueryBuilder[T] {
 	return &QueryBuilder[T]{
 		store:  s,
-		values: values,
+		values: make([]bson.M, 0),
 		limit:  25,
 		skip:   0,
 		sort:   bson.D{},
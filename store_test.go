@@ -1,6 +1,7 @@
 package grimoire
 
 import (
+	"context"
 	"fmt"
 	"testing"
 	"time"
@@ -9,6 +10,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"go.mongodb.org/mongo-driver/bson"
 	"go.mongodb.org/mongo-driver/bson/primitive"
+	"go.mongodb.org/mongo-driver/mongo"
 )
 
 func TestStore_Create(t *testing.T) {
@@ -206,3 +208,54 @@ func TestStore_Index(t *testing.T) {
 	CreateIndexes(s, &Fake{}, "name:text")
 	CreateIndexesFromTags(s, &Fake{})
 }
+
+func TestStore_TenantWrites(t *testing.T) {
+	s, err := New[*Account]("mongodb://localhost:27017", "grimoire", "accounts")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+	assert.NoError(t, s.SetTenancy("tenant_id"))
+
+	acme := WithTenant(context.Background(), "acme")
+	other := WithTenant(context.Background(), "other")
+
+	mine := &Account{Name: "mine"}
+	theirs := &Account{Name: "theirs"}
+	assert.NoError(t, s.SaveManyWithContext(acme, []*Account{mine}))
+	assert.NoError(t, s.SaveManyWithContext(other, []*Account{theirs}))
+
+	// an update of another tenant's document is refused, and nothing is written
+	stolen := &Account{Name: "stolen"}
+	stolen.ID = theirs.ID
+	added := &Account{Name: "added"}
+	mine.Name = "renamed"
+	err = s.SaveManyWithContext(acme, []*Account{mine, stolen, added})
+	assert.ErrorIs(t, err, ErrTenantMismatch)
+	assert.ErrorContains(t, err, theirs.ID.Hex())
+	assert.True(t, added.ID.IsZero(), "not inserted")
+
+	count, err := s.Query().WithContext(acme).Where("name", "mine").Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	assert.NoError(t, s.SaveManyWithContext(acme, []*Account{mine, added}))
+	count, err = s.Query().WithContext(acme).Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), count)
+
+	// documents of other tenants are not found by Update and Delete
+	stolen = &Account{Name: "stolen", Tenant: "acme"}
+	stolen.ID = theirs.ID
+	assert.ErrorIs(t, s.UpdateWithContext(acme, stolen), mongo.ErrNoDocuments)
+	assert.ErrorIs(t, s.DeleteWithContext(acme, theirs), mongo.ErrNoDocuments)
+
+	count, err = s.Query().WithContext(other).Where("name", "theirs").Count()
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), count)
+
+	mine.Name = "mine"
+	assert.NoError(t, s.UpdateWithContext(acme, mine))
+	assert.NoError(t, s.DeleteWithContext(acme, mine))
+	assert.ErrorIs(t, s.DeleteWithContext(acme, mine), mongo.ErrNoDocuments)
+	assert.NoError(t, s.DeleteWithContext(acme, added))
+	assert.NoError(t, s.DeleteWithContext(other, theirs))
+}
@@ -0,0 +1,101 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+var (
+	// ErrUnindexedQuery is returned in strict mode when a query does not use an index.
+	ErrUnindexedQuery = errors.New("query does not use an index")
+	// ErrInefficientQuery is returned in strict mode when a query examines too many documents
+	// for the number of documents it returns.
+	ErrInefficientQuery = errors.New("query examines too many documents")
+)
+
+// StrictMode controls what happens when a query fails the strict checks, see SetStrict.
+type StrictMode int
+
+const (
+	// StrictOff disables the strict checks.
+	StrictOff StrictMode = iota
+	// StrictWarn logs queries that fail the strict checks as warnings, to the logger set with
+	// SetLogger or slog.Default, and runs them anyway.
+	StrictWarn
+	// StrictError returns an error for queries that fail the strict checks, without running them.
+	StrictError
+)
+
+// StrictOptions configures strict mode, see SetStrict.
+type StrictOptions struct {
+	Mode StrictMode
+	// MaxExaminedRatio is the maximum number of documents a find may examine for each document
+	// it returns. Checking the ratio runs the query during the explain. Zero disables the check.
+	MaxExaminedRatio float64
+}
+
+// SetStrict enables strict mode, which explains each QueryBuilder query before running it and
+// rejects queries that do not use an index, or that examine more than MaxExaminedRatio documents
+// for each document returned. This doubles the work of every query, and is intended for
+// development and test environments.
+//
+// Example:
+//
+//	s.SetStrict(StrictOptions{Mode: StrictError, MaxExaminedRatio: 10})
+func (s *Store[T]) SetStrict(opts StrictOptions) {
+	s.strict = opts
+}
+
+// checkStrict explains the query with explain and checks the result against the strict options
+// of the store. The ratio of documents examined is only checked for finds, since counts and
+// deletes do not return documents.
+func (q *QueryBuilder[T]) checkStrict(op string, explain func(verbosity string) (*Explanation, error)) error {
+	opts := q.store.strict
+	if opts.Mode == StrictOff {
+		return nil
+	}
+
+	checkRatio := opts.MaxExaminedRatio > 0 && op == "find"
+	verbosity := ExplainQueryPlanner
+	if checkRatio {
+		verbosity = ExplainExecutionStats
+	}
+
+	e, err := explain(verbosity)
+	if err != nil {
+		return q.strictFailure(op, fmt.Errorf("grimoire: strict: explain: %w", err))
+	}
+
+	if !e.IndexUsed() {
+		return q.strictFailure(op, fmt.Errorf("grimoire: strict: %w: %s: %s", ErrUnindexedQuery, q.Shell(), e))
+	}
+	if checkRatio {
+		returned := e.Returned
+		if returned == 0 {
+			returned = 1
+		}
+		if float64(e.DocsExamined)/float64(returned) > opts.MaxExaminedRatio {
+			return q.strictFailure(op, fmt.Errorf("grimoire: strict: %w: %s: %s", ErrInefficientQuery, q.Shell(), e))
+		}
+	}
+
+	return nil
+}
+
+// strictFailure returns err in StrictError mode, or logs it as a warning to the logger of the
+// store, or slog.Default when the store has none.
+func (q *QueryBuilder[T]) strictFailure(op string, err error) error {
+	if q.store.strict.Mode == StrictError {
+		return err
+	}
+	logger := slog.Default()
+	if l := q.store.logging; l != nil {
+		logger = l.logger
+	}
+	logger.WarnContext(q.context(), "grimoire: strict",
+		slog.String("collection", q.store.Collection.Name()),
+		slog.String("operation", op),
+		slog.String("error", err.Error()))
+	return nil
+}
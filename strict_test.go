@@ -0,0 +1,43 @@
+package grimoire
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQueryBuilder_CheckStrict(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	collscan := &Explanation{Stage: "COLLSCAN", Stages: []string{"COLLSCAN"}, CollectionScan: true, Returned: 1, DocsExamined: 745}
+	ixscan := &Explanation{Stage: "FETCH", Stages: []string{"FETCH", "IXSCAN"}, Indexes: []string{"status_1"}, Returned: 10, DocsExamined: 500}
+	explain := func(e *Explanation) func(string) (*Explanation, error) {
+		return func(verbosity string) (*Explanation, error) {
+			return e, nil
+		}
+	}
+
+	q := s.Query().Where("status", "done")
+
+	// off by default
+	assert.NoError(t, q.checkStrict("find", explain(collscan)))
+
+	s.SetStrict(StrictOptions{Mode: StrictError})
+	assert.ErrorIs(t, q.checkStrict("find", explain(collscan)), ErrUnindexedQuery)
+	assert.NoError(t, q.checkStrict("find", explain(ixscan)))
+
+	s.SetStrict(StrictOptions{Mode: StrictError, MaxExaminedRatio: 10})
+	assert.ErrorIs(t, q.checkStrict("find", explain(ixscan)), ErrInefficientQuery)
+	assert.NoError(t, q.checkStrict("count", explain(ixscan)), "ratio only applies to find")
+
+	s.SetStrict(StrictOptions{Mode: StrictWarn, MaxExaminedRatio: 10})
+	buf := &bytes.Buffer{}
+	s.SetLogger(slog.New(slog.NewTextHandler(buf, nil)), DefaultLogOptions)
+	assert.NoError(t, q.checkStrict("find", explain(collscan)))
+	assert.Contains(t, buf.String(), `level=WARN msg="grimoire: strict" collection=downloads operation=find error=`)
+	assert.Contains(t, buf.String(), "query does not use an index")
+}
@@ -0,0 +1,115 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// ErrInvalidTag is returned for grimoire struct tags that do not follow the grammar of ParseTag.
+var ErrInvalidTag = errors.New("invalid grimoire tag")
+
+// validationRules are the names of the rules checked by Validate.
+var validationRules = map[string]bool{
+	"required": true,
+	"min":      true,
+	"max":      true,
+	"len":      true,
+	"oneof":    true,
+	"regex":    true,
+}
+
+// Tag is a parsed grimoire struct tag, see ParseTag.
+type Tag struct {
+	// Ref is the field holding the ids of a ref tag, e.g. "medium_id" for
+	// `grimoire:"ref,medium_id"`.
+	Ref string
+	// Index is the direction of the index declared by the tag: 1 for `grimoire:"index"` or
+	// `grimoire:"index,asc"`, -1 for `grimoire:"index,desc"`, and 0 without index.
+	Index int
+	// Rules are the validation rules of the tag, in order, e.g. "required" or "oneof=a b".
+	Rules []string
+}
+
+// ParseTag parses the value of a grimoire struct tag, a comma separated list of options:
+//
+//	ref,<field>          a reference loaded from the ids of field, see SetRef; ref takes no
+//	                     other option
+//	index[,asc|desc]     an index on the field, ascending by default, see CreateIndexesFromTags
+//	required             a validation rule, see Validate; the other rules are min=<n>,
+//	                     max=<n>, len=<n>, oneof=<values separated by spaces> and
+//	                     regex=<pattern>
+//
+// index and the validation rules can be combined in any order, e.g.
+// `grimoire:"required,index,desc"`. Each option appears at most once. Unknown options, and ref
+// combined with other options, return ErrInvalidTag.
+// NOTE: rule values cannot contain commas.
+func ParseTag(v string) (Tag, error) {
+	t := Tag{}
+	vals := strings.Split(v, ",")
+	for i := range vals {
+		vals[i] = strings.TrimSpace(vals[i])
+	}
+
+	if vals[0] == "ref" {
+		if len(vals) != 2 || vals[1] == "" {
+			return t, fmt.Errorf("%w %q: ref takes a single field", ErrInvalidTag, v)
+		}
+		t.Ref = vals[1]
+		return t, nil
+	}
+
+	seen := map[string]bool{}
+	for i := 0; i < len(vals); i++ {
+		name, _, _ := strings.Cut(vals[i], "=")
+		if seen[name] {
+			return t, fmt.Errorf("%w %q: duplicate %s", ErrInvalidTag, v, name)
+		}
+		seen[name] = true
+
+		switch {
+		case vals[i] == "index":
+			t.Index = 1
+			if i+1 < len(vals) && (vals[i+1] == "asc" || vals[i+1] == "desc") {
+				if vals[i+1] == "desc" {
+					t.Index = -1
+				}
+				i++
+			}
+		case validationRules[name]:
+			t.Rules = append(t.Rules, vals[i])
+		case name == "ref":
+			return t, fmt.Errorf("%w %q: ref takes no other option", ErrInvalidTag, v)
+		default:
+			return t, fmt.Errorf("%w %q: unknown option %q", ErrInvalidTag, v, vals[i])
+		}
+	}
+	return t, nil
+}
+
+// parseTag parses the grimoire tag of sf, returning false when sf has none.
+func parseTag(sf reflect.StructField) (Tag, bool, error) {
+	v, ok := sf.Tag.Lookup("grimoire")
+	if !ok || v == "" {
+		return Tag{}, false, nil
+	}
+	t, err := ParseTag(v)
+	if err != nil {
+		return Tag{}, false, err
+	}
+	return t, true, nil
+}
+
+// Rule returns the value of the rule name of the tag, for tags listing rules such as
+// `grimoire:"required,oneof=queued done"`. Rules without a value, such as required, return an
+// empty string.
+func (t Tag) Rule(name string) (string, bool) {
+	for _, v := range t.Rules {
+		key, value, _ := strings.Cut(v, "=")
+		if key == name {
+			return value, true
+		}
+	}
+	return "", false
+}
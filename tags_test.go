@@ -0,0 +1,66 @@
+package grimoire
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+func TestParseTag(t *testing.T) {
+	testCases := []struct {
+		value string
+		tag   Tag
+		err   bool
+	}{
+		{value: "ref,medium_id", tag: Tag{Ref: "medium_id"}},
+		{value: "index", tag: Tag{Index: 1}},
+		{value: "index,asc", tag: Tag{Index: 1}},
+		{value: "index, desc", tag: Tag{Index: -1}},
+		{value: "required,index", tag: Tag{Index: 1, Rules: []string{"required"}}},
+		{value: "index,desc,required,oneof=a b", tag: Tag{Index: -1, Rules: []string{"required", "oneof=a b"}}},
+		{value: "min=1,index,max=5", tag: Tag{Index: 1, Rules: []string{"min=1", "max=5"}}},
+		{value: "ref", err: true},
+		{value: "ref,medium_id,required", err: true},
+		{value: "required,ref,medium_id", err: true},
+		{value: "index,index", err: true},
+		{value: "required,desc", err: true},
+		{value: "unique", err: true},
+		{value: "required,", err: true},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.value, func(t *testing.T) {
+			tag, err := ParseTag(tc.value)
+			if tc.err {
+				assert.ErrorIs(t, err, ErrInvalidTag)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.tag, tag)
+		})
+	}
+}
+
+func TestTagIndexes(t *testing.T) {
+	type tagged struct {
+		Document `bson:",inline"`
+		Name     string `bson:"name" grimoire:"required,index"`
+		Age      int    `bson:"age" grimoire:"index,desc,min=1"`
+		Kind     string `bson:"kind" grimoire:"unique"`
+	}
+	assert.Equal(t, []bson.D{
+		{{Key: "name", Value: 1}},
+		{{Key: "age", Value: -1}},
+	}, tagIndexes(reflect.TypeOf(&tagged{})))
+
+	err := Validate(&tagged{Age: 2})
+	assert.ErrorIs(t, err, ErrInvalidTag)
+
+	type rules struct {
+		Document `bson:",inline"`
+		Name     string `bson:"name" grimoire:"required,index"`
+	}
+	verr := &ValidationError{}
+	assert.ErrorAs(t, Validate(&rules{}), &verr)
+}
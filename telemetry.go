@@ -0,0 +1,172 @@
+package grimoire
+
+import (
+	"context"
+	"time"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	metricnoop "go.opentelemetry.io/otel/metric/noop"
+	"go.opentelemetry.io/otel/trace"
+	tracenoop "go.opentelemetry.io/otel/trace/noop"
+)
+
+const instrumentationName = "github.com/dashotv/grimoire"
+
+// instrumentation holds the OpenTelemetry tracer and instruments of a store.
+type instrumentation struct {
+	tracer     trace.Tracer
+	duration   metric.Float64Histogram
+	operations metric.Int64Counter
+	results    metric.Int64Histogram
+}
+
+// SetInstrumentation enables OpenTelemetry tracing and metrics for the store. Each Store and
+// QueryBuilder operation creates a span with the operation name, collection, filter shape
+// (without values), result count and error, and records its duration, outcome and result count.
+// Either provider can be nil to disable traces or metrics.
+//
+// Example:
+//
+//	err := s.SetInstrumentation(otel.GetTracerProvider(), otel.GetMeterProvider())
+func (s *Store[T]) SetInstrumentation(tp trace.TracerProvider, mp metric.MeterProvider) error {
+	if tp == nil {
+		tp = tracenoop.NewTracerProvider()
+	}
+	if mp == nil {
+		mp = metricnoop.NewMeterProvider()
+	}
+
+	meter := mp.Meter(instrumentationName)
+	duration, err := meter.Float64Histogram("grimoire.operation.duration",
+		metric.WithDescription("Duration of store operations"),
+		metric.WithUnit("s"))
+	if err != nil {
+		return err
+	}
+	operations, err := meter.Int64Counter("grimoire.operations",
+		metric.WithDescription("Number of store operations"),
+		metric.WithUnit("{operation}"))
+	if err != nil {
+		return err
+	}
+	results, err := meter.Int64Histogram("grimoire.operation.results",
+		metric.WithDescription("Number of documents returned or affected by store operations"),
+		metric.WithUnit("{document}"))
+	if err != nil {
+		return err
+	}
+
+	s.instrumentation = &instrumentation{
+		tracer:     tp.Tracer(instrumentationName),
+		duration:   duration,
+		operations: operations,
+		results:    results,
+	}
+	return nil
+}
+
+// operation describes a store operation for instrumentation and logging.
+type operation struct {
+	name   string
+	filter interface{}
+	// query returns the full shape of the query, without values, for the slow query log.
+	query func() string
+}
+
+// shape returns the filter of the operation without values.
+func (op operation) shape() string {
+	if op.filter == nil {
+		return ""
+	}
+	return shellValue(filterShape(op.filter))
+}
+
+// observe runs f as the operation op on the store, with a span and metrics when instrumentation
+// is enabled, and a log record when a logger is set. f returns the number of documents returned
+// or affected.
+func (s *Store[T]) observe(ctx context.Context, op operation, f func(ctx context.Context) (int64, error)) error {
+	inst := s.instrumentation
+	if inst == nil && s.logging == nil {
+		_, err := f(ctx)
+		return err
+	}
+
+	var span trace.Span
+	var attrs []attribute.KeyValue
+	if inst != nil {
+		attrs = []attribute.KeyValue{
+			attribute.String("db.system", "mongodb"),
+			attribute.String("db.namespace", s.Database.Name()),
+			attribute.String("db.collection.name", s.Collection.Name()),
+			attribute.String("db.operation.name", op.name),
+		}
+		ctx, span = inst.tracer.Start(ctx, op.name+" "+s.Collection.Name(),
+			trace.WithSpanKind(trace.SpanKindClient),
+			trace.WithAttributes(attrs...))
+		if op.filter != nil {
+			span.SetAttributes(attribute.String("grimoire.filter", op.shape()))
+		}
+	}
+
+	start := time.Now()
+	n, err := f(ctx)
+	elapsed := time.Since(start)
+
+	if inst != nil {
+		span.SetAttributes(attribute.Int64("grimoire.results", n))
+		status := "ok"
+		if err != nil {
+			status = "error"
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+
+		set := metric.WithAttributeSet(attribute.NewSet(append(attrs, attribute.String("grimoire.status", status))...))
+		inst.duration.Record(ctx, elapsed.Seconds(), set)
+		inst.operations.Add(ctx, 1, set)
+		if err == nil {
+			inst.results.Record(ctx, n, set)
+		}
+	}
+
+	if s.logging != nil {
+		s.logging.log(ctx, s.Collection.Name(), op, elapsed, n, err)
+	}
+
+	return err
+}
+
+// filterShape returns a copy of filter with every value replaced by "?", keeping the fields
+// and operators.
+func filterShape(filter interface{}) interface{} {
+	switch v := filter.(type) {
+	case bson.M:
+		out := bson.M{}
+		for k, e := range v {
+			out[k] = filterShape(e)
+		}
+		return out
+	case bson.D:
+		out := bson.D{}
+		for _, e := range v {
+			out = append(out, bson.E{Key: e.Key, Value: filterShape(e.Value)})
+		}
+		return out
+	case []bson.M:
+		out := bson.A{}
+		for _, e := range v {
+			out = append(out, filterShape(e))
+		}
+		return out
+	case bson.A:
+		// arrays of conditions keep their shape, arrays of values do not
+		if list, ok := conditionList(v); ok {
+			return filterShape(list)
+		}
+	}
+	return "?"
+}
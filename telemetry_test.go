@@ -0,0 +1,77 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestStore_Instrumentation(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	spans := tracetest.NewSpanRecorder()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(spans))
+	reader := sdkmetric.NewManualReader()
+	mp := sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader))
+	assert.NoError(t, s.SetInstrumentation(tp, mp))
+
+	filter := s.Query().Where("status", "done").In("thash", []string{"a", "b"}).filter()
+	err = s.observe(context.Background(), operation{name: "find", filter: filter}, func(ctx context.Context) (int64, error) {
+		return 3, nil
+	})
+	assert.NoError(t, err)
+	err = s.observe(context.Background(), operation{name: "delete", filter: bson.M{"_id": "abc"}}, func(ctx context.Context) (int64, error) {
+		return 0, errors.New("boom")
+	})
+	assert.Error(t, err)
+
+	ended := spans.Ended()
+	if assert.Len(t, ended, 2) {
+		find := ended[0]
+		assert.Equal(t, "find downloads", find.Name())
+		attrs := attribute.NewSet(find.Attributes()...)
+		v, _ := attrs.Value("grimoire.filter")
+		assert.Equal(t, `{"$and": [{"status": {"$eq": "?"}}, {"thash": {"$in": "?"}}]}`, v.AsString())
+		v, _ = attrs.Value("grimoire.results")
+		assert.Equal(t, int64(3), v.AsInt64())
+		v, _ = attrs.Value("db.collection.name")
+		assert.Equal(t, "downloads", v.AsString())
+
+		assert.Equal(t, codes.Error, ended[1].Status().Code)
+		assert.Len(t, ended[1].Events(), 1, "error event")
+	}
+
+	rm := metricdata.ResourceMetrics{}
+	assert.NoError(t, reader.Collect(context.Background(), &rm))
+	metrics := map[string]metricdata.Aggregation{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			metrics[m.Name] = m.Data
+		}
+	}
+	if sum, ok := metrics["grimoire.operations"].(metricdata.Sum[int64]); assert.True(t, ok) {
+		total := int64(0)
+		for _, dp := range sum.DataPoints {
+			total += dp.Value
+		}
+		assert.Equal(t, int64(2), total)
+	}
+	if hist, ok := metrics["grimoire.operation.duration"].(metricdata.Histogram[float64]); assert.True(t, ok) {
+		assert.Len(t, hist.DataPoints, 2)
+	}
+	if hist, ok := metrics["grimoire.operation.results"].(metricdata.Histogram[int64]); assert.True(t, ok) {
+		assert.Len(t, hist.DataPoints, 1)
+		assert.Equal(t, int64(3), hist.DataPoints[0].Sum)
+	}
+}
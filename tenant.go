@@ -0,0 +1,129 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+var (
+	// ErrNoTenant is returned by tenant stores when the context has no tenant, see WithTenant.
+	ErrNoTenant = errors.New("no tenant in context")
+	// ErrTenantMismatch is returned by tenant stores when a document belongs to another tenant.
+	ErrTenantMismatch = errors.New("document belongs to another tenant")
+)
+
+type tenantKey struct{}
+
+// WithTenant returns a copy of ctx carrying the tenant, for use with tenant stores.
+//
+// Example:
+//
+//	ctx := WithTenant(r.Context(), "acme")
+func WithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by ctx.
+func TenantFromContext(ctx context.Context) (string, bool) {
+	tenant, ok := ctx.Value(tenantKey{}).(string)
+	return tenant, ok && tenant != ""
+}
+
+// SetTenancy makes the store a tenant store, which keeps the documents of each tenant in the
+// string field of T. Every operation reads the tenant from its context: queries, counts and
+// deletes only match the documents of the tenant, saves set the field, and Get, Find, Update
+// and Delete refuse documents of other tenants. Operations fail with ErrNoTenant when the
+// context has no tenant, so the store methods without a context cannot be used.
+// NOTE: field should be a valid BSON field.
+//
+// Example:
+//
+//	err := s.SetTenancy("tenant_id")
+//	list, err := s.Query().WithContext(WithTenant(ctx, "acme")).Run()
+func (s *Store[T]) SetTenancy(field string) error {
+	v := reflect.New(modelType[T]()).Elem()
+	f, ok := fieldByBSONName(v, field)
+	if !ok {
+		return fmt.Errorf("grimoire: tenancy: %w: %s", ErrUnknownField, field)
+	}
+	if f.Kind() != reflect.String {
+		return fmt.Errorf("grimoire: tenancy: %s should be a string, not %s", field, f.Type())
+	}
+	s.tenantField = field
+	return nil
+}
+
+// tenant returns the tenant of ctx, or an empty string when the store is not a tenant store.
+func (s *Store[T]) tenant(ctx context.Context) (string, error) {
+	if s.tenantField == "" {
+		return "", nil
+	}
+	tenant, ok := TenantFromContext(ctx)
+	if !ok {
+		return "", fmt.Errorf("grimoire: %w", ErrNoTenant)
+	}
+	return tenant, nil
+}
+
+// tenantFilter returns filter restricted to the tenant of ctx.
+func (s *Store[T]) tenantFilter(ctx context.Context, filter bson.M) (bson.M, error) {
+	tenant, err := s.tenant(ctx)
+	if err != nil || tenant == "" {
+		return filter, err
+	}
+	if _, ok := filter[s.tenantField]; ok {
+		return bson.M{"$and": []bson.M{{s.tenantField: tenant}, filter}}, nil
+	}
+	out := bson.M{s.tenantField: tenant}
+	for k, v := range filter {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// stampTenant sets the tenant field of o to the tenant of ctx.
+func (s *Store[T]) stampTenant(ctx context.Context, o T) error {
+	tenant, err := s.tenant(ctx)
+	if err != nil || tenant == "" {
+		return err
+	}
+	f, err := s.tenantValue(o)
+	if err != nil {
+		return err
+	}
+	if f.String() != "" && f.String() != tenant {
+		return fmt.Errorf("grimoire: %w", ErrTenantMismatch)
+	}
+	f.SetString(tenant)
+	return nil
+}
+
+// tenantValue returns the settable tenant field of o.
+func (s *Store[T]) tenantValue(o T) (reflect.Value, error) {
+	v := reflect.ValueOf(o)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("grimoire: tenancy: nil %T", o)
+		}
+		v = v.Elem()
+	}
+	f, ok := fieldByBSONName(v, s.tenantField)
+	if !ok || !f.CanSet() {
+		return reflect.Value{}, fmt.Errorf("grimoire: tenancy: %w: %s", ErrUnknownField, s.tenantField)
+	}
+	return f, nil
+}
+
+// tenantClause returns the clause restricting a query to the tenant of ctx, or nil when the
+// store is not a tenant store or ctx has no tenant.
+func (s *Store[T]) tenantClause(ctx context.Context) bson.M {
+	tenant, err := s.tenant(ctx)
+	if err != nil || tenant == "" {
+		return nil
+	}
+	return bson.M{s.tenantField: tenant}
+}
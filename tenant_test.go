@@ -0,0 +1,62 @@
+package grimoire
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Account struct {
+	Document `bson:",inline"` // include mgm.DefaultModel
+	Tenant   string           `json:"tenant_id" bson:"tenant_id"`
+	Name     string           `json:"name" bson:"name"`
+}
+
+func TestStore_Tenancy(t *testing.T) {
+	s, err := New[*Account]("mongodb://localhost:27017", "grimoire", "accounts")
+	assert.NoError(t, err)
+	assert.NotNil(t, s)
+
+	assert.True(t, errors.Is(s.SetTenancy("missing"), ErrUnknownField))
+	assert.Error(t, s.SetTenancy("created_at"), "not a string")
+	assert.NoError(t, s.SetTenancy("tenant_id"))
+
+	_, ok := TenantFromContext(context.Background())
+	assert.False(t, ok)
+	acme := WithTenant(context.Background(), "acme")
+	tenant, ok := TenantFromContext(acme)
+	assert.True(t, ok)
+	assert.Equal(t, "acme", tenant)
+
+	// queries
+	q := s.Query().Where("name", "x").WithContext(acme)
+	assert.Equal(t, bson.M{"$and": []bson.M{{"tenant_id": "acme"}, {"name": bson.M{"$eq": "x"}}}}, q.filter())
+	assert.Equal(t, q.filter(), q.Unscoped().filter(), "tenant clause is not a scope")
+	assert.True(t, errors.Is(s.Query().before("find"), ErrNoTenant))
+	assert.NoError(t, q.before("find"))
+	_, err = s.Query().Raw(bson.M{})
+	assert.True(t, errors.Is(err, ErrNoTenant))
+	_, err = s.Count(bson.M{})
+	assert.True(t, errors.Is(err, ErrNoTenant))
+
+	filter, err := s.tenantFilter(acme, bson.M{"_id": "abc"})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"_id": "abc", "tenant_id": "acme"}, filter)
+	filter, err = s.tenantFilter(acme, bson.M{"tenant_id": "other"})
+	assert.NoError(t, err)
+	assert.Equal(t, bson.M{"$and": []bson.M{{"tenant_id": "acme"}, {"tenant_id": "other"}}}, filter)
+
+	// documents
+	a := &Account{Name: "x"}
+	assert.NoError(t, s.stampTenant(acme, a))
+	assert.Equal(t, "acme", a.Tenant)
+	a.Tenant = "other"
+	assert.True(t, errors.Is(s.stampTenant(acme, a), ErrTenantMismatch))
+	assert.True(t, errors.Is(s.Save(a), ErrNoTenant))
+	assert.True(t, errors.Is(s.Delete(a), ErrNoTenant))
+	assert.True(t, errors.Is(s.FindByID(primitive.NewObjectID(), &Account{}), ErrNoTenant))
+}
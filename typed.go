@@ -0,0 +1,99 @@
+package grimoire
+
+import "github.com/kamva/mgm/v3"
+
+// Field is a typed field of the model T, used by the query builders generated by grimoire-gen.
+// Q is the generated query type, which wraps QueryBuilder[T], and V is the Go type of the values
+// of the field, or of its elements for slices. The methods of Field add a condition or a sort to
+// the query and return the query.
+// NOTE: the methods Q inherits from QueryBuilder, such as Limit or Preload, return the
+// *QueryBuilder[T] and not Q, so field methods cannot follow them in a chain. Call the field
+// methods first, or call the QueryBuilder methods in their own statements, since they modify
+// the query in place unless it is immutable.
+//
+// Example:
+//
+//	list, err := s.Query().Status().Eq("done").CreatedAt().Desc().Limit(10).Run()
+//
+//	q := s.Query()
+//	q.Limit(10).Preload("Medium")
+//	list, err := q.Status().Eq("done").Run()
+type Field[T mgm.Model, Q any, V any] struct {
+	name string
+	q    *QueryBuilder[T]
+	wrap func(*QueryBuilder[T]) Q
+}
+
+// NewField returns the field name of the query q, whose methods return the query wrapped with
+// wrap.
+// NOTE: name should be a valid BSON field.
+func NewField[T mgm.Model, Q any, V any](q *QueryBuilder[T], name string, wrap func(*QueryBuilder[T]) Q) Field[T, Q, V] {
+	return Field[T, Q, V]{name: name, q: q, wrap: wrap}
+}
+
+// Name returns the BSON name of the field.
+func (f Field[T, Q, V]) Name() string {
+	return f.name
+}
+
+// Eq adds a condition that the field equals value, see QueryBuilder.Where.
+func (f Field[T, Q, V]) Eq(value V) Q {
+	return f.wrap(f.q.Where(f.name, value))
+}
+
+// Ne adds a condition that the field does not equal value, see QueryBuilder.NotEqual.
+func (f Field[T, Q, V]) Ne(value V) Q {
+	return f.wrap(f.q.NotEqual(f.name, value))
+}
+
+// In adds a condition that the field is one of values, see QueryBuilder.In.
+func (f Field[T, Q, V]) In(values ...V) Q {
+	return f.wrap(f.q.In(f.name, values))
+}
+
+// NotIn adds a condition that the field is none of values, see QueryBuilder.NotIn.
+func (f Field[T, Q, V]) NotIn(values ...V) Q {
+	return f.wrap(f.q.NotIn(f.name, values))
+}
+
+// Gt adds a condition that the field is greater than value, see QueryBuilder.GreaterThan.
+func (f Field[T, Q, V]) Gt(value V) Q {
+	return f.wrap(f.q.GreaterThan(f.name, value))
+}
+
+// Gte adds a condition that the field is greater than or equal to value, see
+// QueryBuilder.GreaterThanEqual.
+func (f Field[T, Q, V]) Gte(value V) Q {
+	return f.wrap(f.q.GreaterThanEqual(f.name, value))
+}
+
+// Lt adds a condition that the field is less than value, see QueryBuilder.LessThan.
+func (f Field[T, Q, V]) Lt(value V) Q {
+	return f.wrap(f.q.LessThan(f.name, value))
+}
+
+// Lte adds a condition that the field is less than or equal to value, see
+// QueryBuilder.LessThanEqual.
+func (f Field[T, Q, V]) Lte(value V) Q {
+	return f.wrap(f.q.LessThanEqual(f.name, value))
+}
+
+// Exists adds a condition that the field exists, see QueryBuilder.Exists.
+func (f Field[T, Q, V]) Exists() Q {
+	return f.wrap(f.q.Exists(f.name))
+}
+
+// NotExists adds a condition that the field does not exist, see QueryBuilder.NotExists.
+func (f Field[T, Q, V]) NotExists() Q {
+	return f.wrap(f.q.NotExists(f.name))
+}
+
+// Asc sorts the query by the field, in ascending order.
+func (f Field[T, Q, V]) Asc() Q {
+	return f.wrap(f.q.Asc(f.name))
+}
+
+// Desc sorts the query by the field, in descending order.
+func (f Field[T, Q, V]) Desc() Q {
+	return f.wrap(f.q.Desc(f.name))
+}
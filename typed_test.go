@@ -0,0 +1,46 @@
+package grimoire
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson"
+)
+
+type downloadQuery struct {
+	*QueryBuilder[*Download]
+}
+
+func wrapDownloadQuery(q *QueryBuilder[*Download]) *downloadQuery {
+	return &downloadQuery{QueryBuilder: q}
+}
+
+func (q *downloadQuery) Status() Field[*Download, *downloadQuery, string] {
+	return NewField[*Download, *downloadQuery, string](q.QueryBuilder, "status", wrapDownloadQuery)
+}
+
+func (q *downloadQuery) Num() Field[*Download, *downloadQuery, int] {
+	return NewField[*Download, *downloadQuery, int](q.QueryBuilder, "files.num", wrapDownloadQuery)
+}
+
+func TestField(t *testing.T) {
+	s, err := New[*Download]("mongodb://localhost:27017", "seer_development", "downloads")
+	assert.NoError(t, err)
+
+	q := wrapDownloadQuery(s.Query())
+	assert.Equal(t, "status", q.Status().Name())
+
+	q = q.Status().In("queued", "done").Num().Gte(2).Num().Ne(5).Status().Desc()
+	assert.Equal(t, bson.M{"$and": []bson.M{
+		{"status": bson.M{"$in": []string{"queued", "done"}}},
+		{"files.num": bson.M{"$gte": 2}},
+		{"files.num": bson.M{"$ne": 5}},
+	}}, q.filter())
+	assert.Equal(t, bson.D{{Key: "status", Value: -1}}, q.sort)
+
+	// immutable queries are not changed
+	base := wrapDownloadQuery(s.Query().Immutable())
+	done := base.Status().Eq("done")
+	assert.Equal(t, bson.M{}, base.filter())
+	assert.Equal(t, bson.M{"$and": []bson.M{{"status": bson.M{"$eq": "done"}}}}, done.filter())
+}
@@ -0,0 +1,370 @@
+package grimoire
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Validator is implemented by models with custom validation, which is run by Validate after the
+// tag rules. Returning a *ValidationError reports several fields.
+type Validator interface {
+	Validate() error
+}
+
+// FieldError is a field that failed a validation rule.
+type FieldError struct {
+	// Path is the dotted BSON path of the field, e.g. "files.0.num". It is empty for errors
+	// returned by Validator without a field.
+	Path string
+	// Rule is the failing rule, e.g. "required" or "oneof", or "custom" for Validator errors.
+	Rule    string
+	Message string
+}
+
+func (e FieldError) Error() string {
+	if e.Path == "" {
+		return e.Message
+	}
+	return e.Path + ": " + e.Message
+}
+
+// ValidationError lists the fields of a document that failed validation.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	list := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		list[i] = f.Error()
+	}
+	return "grimoire: invalid document: " + strings.Join(list, "; ")
+}
+
+// Has returns true when the field with path failed validation.
+func (e *ValidationError) Has(path string) bool {
+	for _, f := range e.Fields {
+		if f.Path == path {
+			return true
+		}
+	}
+	return false
+}
+
+func (e *ValidationError) add(path, rule, format string, args ...interface{}) {
+	e.Fields = append(e.Fields, FieldError{Path: path, Rule: rule, Message: fmt.Sprintf(format, args...)})
+}
+
+// Validate checks the rules of the grimoire struct tags of o, then runs its Validate method if o
+// implements Validator. The error is a *ValidationError listing every failing field. Save,
+// Update and SaveMany validate documents before writing them.
+//
+// The rules are:
+//   - required: the field is not a zero value, nor an empty slice or map
+//   - min=N, max=N: the number is at least or at most N, or the length of strings, slices and
+//     maps is
+//   - len=N: the length of strings, slices and maps is N
+//   - oneof=a b c: the value is one of the space-separated values
+//   - regex=EXPR: the string matches the regular expression
+//
+// Rules other than required are not checked on empty strings, slices and maps, nor on other zero
+// values except numbers: min=1 rejects 0. Nested structs and slices of structs are validated too.
+// NOTE: rule values cannot contain commas.
+//
+// Example:
+//
+//	type Download struct {
+//		Document `bson:",inline"`
+//		Status   string `bson:"status" grimoire:"required,oneof=queued loading done"`
+//		Url      string `bson:"url" grimoire:"regex=^https?://"`
+//		Tags     []string `bson:"tags" grimoire:"max=10"`
+//	}
+func Validate(o interface{}) error {
+	v := reflect.ValueOf(o)
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	verr := &ValidationError{}
+	if err := validateStruct(verr, v, ""); err != nil {
+		return err
+	}
+
+	if val, ok := o.(Validator); ok {
+		if err := val.Validate(); err != nil {
+			var other *ValidationError
+			if errors.As(err, &other) {
+				verr.Fields = append(verr.Fields, other.Fields...)
+			} else {
+				verr.add("", "custom", "%s", err)
+			}
+		}
+	}
+
+	if len(verr.Fields) > 0 {
+		return verr
+	}
+	return nil
+}
+
+// fieldRules are the validation rules of a struct field.
+type fieldRules struct {
+	index    []int
+	name     string
+	required bool
+	min, max *float64
+	length   *int
+	oneof    []string
+	regex    *regexp.Regexp
+	// nested is true for structs, and slices of structs, which are validated recursively.
+	nested bool
+}
+
+type typeRules struct {
+	fields []fieldRules
+	err    error
+}
+
+// rulesCache caches the rules of struct types.
+var rulesCache sync.Map // map[reflect.Type]*typeRules
+
+func rulesOf(t reflect.Type) ([]fieldRules, error) {
+	if r, ok := rulesCache.Load(t); ok {
+		return r.(*typeRules).fields, r.(*typeRules).err
+	}
+	fields, err := compileRules(t, nil)
+	r, _ := rulesCache.LoadOrStore(t, &typeRules{fields: fields, err: err})
+	return r.(*typeRules).fields, r.(*typeRules).err
+}
+
+// compileRules returns the rules of the fields of the struct type t, merging the fields of
+// inline structs.
+func compileRules(t reflect.Type, index []int) ([]fieldRules, error) {
+	list := []fieldRules{}
+	for i := 0; i < t.NumField(); i++ {
+		sf := t.Field(i)
+		if sf.PkgPath != "" {
+			continue // unexported
+		}
+		name, inline, skip := bsonName(sf)
+		if skip {
+			continue
+		}
+		fi := append(append([]int{}, index...), i)
+		if inline {
+			if sf.Type.Kind() == reflect.Struct {
+				inner, err := compileRules(sf.Type, fi)
+				if err != nil {
+					return nil, err
+				}
+				list = append(list, inner...)
+			}
+			continue
+		}
+
+		r := fieldRules{index: fi, name: name}
+		ft := indirect(sf.Type)
+		if ft.Kind() == reflect.Slice || ft.Kind() == reflect.Array {
+			ft = indirect(ft.Elem())
+		}
+		r.nested = ft.Kind() == reflect.Struct && ft != timeType
+
+		tag, ok, err := parseTag(sf)
+		if err != nil {
+			return nil, fmt.Errorf("grimoire: rules of %s: %w", sf.Name, err)
+		}
+		if ok {
+			if err := r.parse(tag); err != nil {
+				return nil, fmt.Errorf("grimoire: rules of %s: %w", sf.Name, err)
+			}
+		}
+		if r.required || r.min != nil || r.max != nil || r.length != nil || r.oneof != nil || r.regex != nil || r.nested {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+func (r *fieldRules) parse(tag Tag) error {
+	_, r.required = tag.Rule("required")
+	for _, rule := range []string{"min", "max"} {
+		v, ok := tag.Rule(rule)
+		if !ok {
+			continue
+		}
+		n, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %w", rule, err)
+		}
+		if rule == "min" {
+			r.min = &n
+		} else {
+			r.max = &n
+		}
+	}
+	if v, ok := tag.Rule("len"); ok {
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			return fmt.Errorf("len: %w", err)
+		}
+		r.length = &n
+	}
+	if v, ok := tag.Rule("oneof"); ok {
+		r.oneof = strings.Fields(v)
+	}
+	if v, ok := tag.Rule("regex"); ok {
+		re, err := regexp.Compile(v)
+		if err != nil {
+			return fmt.Errorf("regex: %w", err)
+		}
+		r.regex = re
+	}
+	return nil
+}
+
+// validateStruct adds the fields of the struct v that fail their rules to verr.
+func validateStruct(verr *ValidationError, v reflect.Value, prefix string) error {
+	rules, err := rulesOf(v.Type())
+	if err != nil {
+		return err
+	}
+	for _, r := range rules {
+		f, ok := fieldByIndex(v, r.index)
+		if !ok {
+			continue // nil inline pointer
+		}
+		if err := r.check(verr, f, prefix+r.name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// numericKind returns true for the kinds of numbers.
+func numericKind(k reflect.Kind) bool {
+	switch k {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+	return false
+}
+
+// check adds the rules failed by the value f of the field at path to verr.
+func (r fieldRules) check(verr *ValidationError, f reflect.Value, path string) error {
+	for f.Kind() == reflect.Ptr || f.Kind() == reflect.Interface {
+		if f.IsNil() {
+			if r.required {
+				verr.add(path, "required", "is required")
+			}
+			return nil
+		}
+		f = f.Elem()
+	}
+
+	zero := f.IsZero()
+	if (f.Kind() == reflect.Slice || f.Kind() == reflect.Map) && f.Len() == 0 {
+		zero = true
+	}
+	if zero && r.required {
+		verr.add(path, "required", "is required")
+	}
+	// the other rules check zero numbers, so that min=1 rejects 0
+	if zero && !numericKind(f.Kind()) {
+		if r.nested && f.Kind() == reflect.Struct {
+			return validateStruct(verr, f, path+".")
+		}
+		return nil
+	}
+
+	size, sized := 0, false
+	num, numeric := 0.0, false
+	switch f.Kind() {
+	case reflect.String, reflect.Slice, reflect.Array, reflect.Map:
+		size, sized = f.Len(), true
+		if f.Kind() == reflect.String {
+			size = len([]rune(f.String()))
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, numeric = float64(f.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, numeric = float64(f.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		num, numeric = f.Float(), true
+	}
+
+	if r.min != nil {
+		if numeric && num < *r.min {
+			verr.add(path, "min", "must be at least %v", *r.min)
+		} else if sized && float64(size) < *r.min {
+			verr.add(path, "min", "must have a length of at least %v", *r.min)
+		}
+	}
+	if r.max != nil {
+		if numeric && num > *r.max {
+			verr.add(path, "max", "must be at most %v", *r.max)
+		} else if sized && float64(size) > *r.max {
+			verr.add(path, "max", "must have a length of at most %v", *r.max)
+		}
+	}
+	if r.length != nil && sized && size != *r.length {
+		verr.add(path, "len", "must have a length of %d", *r.length)
+	}
+	if r.oneof != nil && !contains(r.oneof, fmt.Sprint(f.Interface())) {
+		verr.add(path, "oneof", "must be one of %s", strings.Join(r.oneof, ", "))
+	}
+	if r.regex != nil && f.Kind() == reflect.String && !r.regex.MatchString(f.String()) {
+		verr.add(path, "regex", "must match %s", r.regex)
+	}
+
+	if !r.nested {
+		return nil
+	}
+	if f.Kind() == reflect.Struct {
+		return validateStruct(verr, f, path+".")
+	}
+	for i := 0; i < f.Len(); i++ {
+		e := f.Index(i)
+		for e.Kind() == reflect.Ptr {
+			if e.IsNil() {
+				break
+			}
+			e = e.Elem()
+		}
+		if e.Kind() != reflect.Struct {
+			continue
+		}
+		if err := validateStruct(verr, e, fmt.Sprintf("%s.%d.", path, i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// fieldByIndex returns the field of v with index, or false when it is behind a nil pointer.
+func fieldByIndex(v reflect.Value, index []int) (reflect.Value, bool) {
+	for i, x := range index {
+		if i > 0 {
+			for v.Kind() == reflect.Ptr {
+				if v.IsNil() {
+					return reflect.Value{}, false
+				}
+				v = v.Elem()
+			}
+		}
+		v = v.Field(x)
+	}
+	return v, true
+}
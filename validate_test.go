@@ -0,0 +1,147 @@
+package grimoire
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+type Release struct {
+	Document `bson:",inline"`
+	Title    string   `bson:"title" grimoire:"required,min=2,max=20"`
+	Status   string   `bson:"status" grimoire:"oneof=new verified"`
+	Checksum string   `bson:"checksum" grimoire:"len=8,regex=^[0-9a-f]+$"`
+	Size     int64    `bson:"size" grimoire:"min=1,max=1000"`
+	Tags     []string `bson:"tags" grimoire:"max=2"`
+	Source   *string  `bson:"source" grimoire:"required"`
+	Files    []struct {
+		Name string `bson:"name" grimoire:"required"`
+		Num  int    `bson:"num" grimoire:"min=1"`
+	} `bson:"files"`
+	Meta struct {
+		Group string `bson:"group" grimoire:"required"`
+	} `bson:"meta"`
+}
+
+func (r *Release) Validate() error {
+	if r.Status == "verified" && r.Checksum == "" {
+		return &ValidationError{Fields: []FieldError{{Path: "checksum", Rule: "custom", Message: "is required when verified"}}}
+	}
+	if r.Title == "forbidden" {
+		return errors.New("title is forbidden")
+	}
+	return nil
+}
+
+func TestValidate(t *testing.T) {
+	source := "usenet"
+	valid := func() *Release {
+		r := &Release{Title: "Title", Status: "new", Checksum: "0123abcd", Size: 10, Tags: []string{"a"}, Source: &source}
+		r.Meta.Group = "group"
+		return r
+	}
+	assert.NoError(t, Validate(valid()))
+
+	r := valid()
+	r.Title = "T"
+	r.Status = "old"
+	r.Checksum = "XYZ"
+	r.Size = 1001
+	r.Tags = []string{"a", "b", "c"}
+	r.Source = nil
+	r.Files = append(r.Files, struct {
+		Name string `bson:"name" grimoire:"required"`
+		Num  int    `bson:"num" grimoire:"min=1"`
+	}{Num: -1})
+	r.Meta.Group = ""
+
+	err := Validate(r)
+	var verr *ValidationError
+	if assert.True(t, errors.As(err, &verr)) {
+		rules := map[string][]string{}
+		for _, f := range verr.Fields {
+			rules[f.Path] = append(rules[f.Path], f.Rule)
+		}
+		assert.Equal(t, map[string][]string{
+			"title":        {"min"},
+			"status":       {"oneof"},
+			"checksum":     {"len", "regex"},
+			"size":         {"max"},
+			"tags":         {"max"},
+			"source":       {"required"},
+			"files.0.name": {"required"},
+			"files.0.num":  {"min"},
+			"meta.group":   {"required"},
+		}, rules)
+		assert.True(t, verr.Has("files.0.num"))
+		assert.Contains(t, err.Error(), "grimoire: invalid document: title: must have a length of at least 2")
+	}
+
+	// empty strings, slices and maps are only checked by required
+	r = valid()
+	r.Status = ""
+	r.Checksum = ""
+	r.Tags = nil
+	assert.NoError(t, Validate(r))
+
+	// zero numbers are checked
+	r = valid()
+	r.Size = 0
+	err = Validate(r)
+	if assert.True(t, errors.As(err, &verr)) {
+		assert.Equal(t, []FieldError{{Path: "size", Rule: "min", Message: "must be at least 1"}}, verr.Fields)
+	}
+	type level struct {
+		Level int `bson:"level" grimoire:"oneof=1 2 3"`
+	}
+	err = Validate(&level{})
+	if assert.True(t, errors.As(err, &verr)) {
+		assert.True(t, verr.Has("level"))
+	}
+	assert.NoError(t, Validate(&level{Level: 2}))
+
+	// custom validation
+	r = valid()
+	r.Status = "verified"
+	r.Checksum = ""
+	err = Validate(r)
+	if assert.True(t, errors.As(err, &verr)) {
+		assert.Equal(t, []FieldError{{Path: "checksum", Rule: "custom", Message: "is required when verified"}}, verr.Fields)
+	}
+	r = valid()
+	r.Title = "forbidden"
+	assert.EqualError(t, Validate(r), "grimoire: invalid document: title is forbidden")
+
+	// invalid rules
+	type bad struct {
+		Document `bson:",inline"`
+		Name     string `bson:"name" grimoire:"regex=["`
+	}
+	err = Validate(&bad{Name: "x"})
+	assert.Error(t, err)
+	assert.False(t, errors.As(err, &verr))
+}
+
+func TestStore_SaveManyValidation(t *testing.T) {
+	s, err := New[*Release]("mongodb://localhost:27017", "seer_development", "releases")
+	assert.NoError(t, err)
+
+	source := "usenet"
+	ok := &Release{Title: "Title", Size: 1, Source: &source}
+	ok.Meta.Group = "group"
+	invalid := &Release{Title: "Title", Size: 1}
+	invalid.Meta.Group = "group"
+
+	err = s.SaveMany([]*Release{ok, invalid})
+	var verr *ValidationError
+	if assert.True(t, errors.As(err, &verr)) {
+		assert.Equal(t, []FieldError{{Path: "1.source", Rule: "required", Message: "is required"}}, verr.Fields)
+	}
+	assert.Equal(t, primitive.NilObjectID, ok.ID, "nothing written")
+
+	err = s.Save(invalid)
+	assert.True(t, errors.As(err, &verr))
+	assert.True(t, verr.Has("source"))
+}